@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	maxExtraRelays      = 8
+	extraRelayTimeout   = 5 * time.Second
+	maxAuthorsPerFilter = 100 // authors per filter in split queries, see authorFilters
+)
+
+// planExtraRelays decides which relays outside our configuration to ask for
+// which authors (NIP-65 outbox model). Authors that publish to one of our
+// read relays are left to the pool; for the others, relays are picked greedily
+// by the number of uncovered authors they serve, at most limit of them.
+// Follow.Relays hints count like write relays from a relay list.
+func planExtraRelays(keys []string, limit int) map[string][]string {
+	ours := make(map[string]bool)
+	for url, policy := range config.Relays {
+		if policy.Read {
+			ours[nostr.NormalizeURL(url)] = true
+		}
+	}
+
+	lists := fetchRelayLists(keys, false)
+	candidates := make(map[string]map[string]bool) // author -> relays
+	for _, key := range keys {
+		relays := make(map[string]bool)
+		for url, policy := range lists[key].Relays {
+			if policy.Write {
+				relays[url] = true
+			}
+		}
+		for _, url := range config.Following[key].Relays {
+			if url = nostr.NormalizeURL(url); url != "" {
+				relays[url] = true
+			}
+		}
+		covered := false
+		for url := range relays {
+			covered = covered || ours[url]
+		}
+		if !covered && len(relays) > 0 {
+			candidates[key] = relays
+		}
+	}
+
+	plan := make(map[string][]string)
+	for len(plan) < limit && len(candidates) > 0 {
+		counts := make(map[string]int)
+		for _, relays := range candidates {
+			for url := range relays {
+				counts[url]++
+			}
+		}
+		best := ""
+		for url, n := range counts {
+			if best == "" || n > counts[best] || (n == counts[best] && url < best) {
+				best = url
+			}
+		}
+		for key, relays := range candidates {
+			if relays[best] {
+				plan[best] = append(plan[best], key)
+				delete(candidates, key)
+			}
+		}
+		sort.Strings(plan[best])
+	}
+	return plan
+}
+
+// authorSub merges a pool subscription for some authors with temporary
+// subscriptions on the extra relays those authors publish to. Events, like
+// the pool's, is never closed; call Close to end the subscriptions and drop
+// the extra connections.
+type authorSub struct {
+	Events chan nostr.EventMessage
+	extra  int // number of extra relays being asked
+
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	relays []*nostr.Relay
+	subs   []*nostr.Subscription // on the pool's relays
+	heard  atomic.Bool           // a pool relay sent an event
+	eose   bool                  // or said it had none
+}
+
+func newAuthorSub() *authorSub {
+	return &authorSub{
+		Events: make(chan nostr.EventMessage),
+		done:   make(chan struct{}),
+	}
+}
+
+// subscribePool asks the relays of the pool for filters. The pool must be
+// initialized.
+func subscribePool(filters nostr.Filters) *authorSub {
+	s := newAuthorSub()
+	s.subscribePool(filters)
+	return s
+}
+
+// subscribePool adds a subscription to filters on every relay of the pool.
+// Unlike pool.Sub it keeps the relay subscriptions, so that Close can end
+// them; the pool offers no way to.
+func (s *authorSub) subscribePool(filters nostr.Filters) {
+	pool.Relays.Range(func(_ string, relay *nostr.Relay) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-s.done:
+			return false
+		default:
+		}
+		sub := relay.Subscribe(filters)
+		s.subs = append(s.subs, sub)
+		go s.forward(sub)
+		return true
+	})
+}
+
+// subscribeRelays asks only the relays at urls, leaving the pool out.
+func subscribeRelays(urls []string, filters nostr.Filters) *authorSub {
+	s := newAuthorSub()
+	s.extra = len(urls)
+	for _, url := range urls {
+		go s.subscribeExtra(nostr.NormalizeURL(url), filters)
+	}
+	return s
+}
+
+// subscribeAuthors asks the pool and the authors' own write relays for events
+// matching filter by any of keys. The pool must be initialized.
+func subscribeAuthors(filter nostr.Filter, keys []string) *authorSub {
+	s := newAuthorSub()
+	poolFilter := filter
+	poolFilter.Authors = keys
+	s.subscribePool(nostr.Filters{poolFilter})
+
+	plan := planExtraRelays(keys, maxExtraRelays)
+	s.extra = len(plan)
+	for url, authors := range plan {
+		go s.subscribeExtra(url, authorFilters(filter, authors))
+	}
+	return s
+}
+
+// authorFilters copies filter for keys, at most maxAuthorsPerFilter of them
+// per copy.
+func authorFilters(filter nostr.Filter, keys []string) nostr.Filters {
+	var filters nostr.Filters
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > maxAuthorsPerFilter {
+			n = maxAuthorsPerFilter
+		}
+		f := filter
+		f.Authors = keys[:n]
+		keys = keys[n:]
+		filters = append(filters, f)
+	}
+	return filters
+}
+
+// forward passes the events of a pool relay subscription on. Once s is
+// closed it keeps draining them for as long as the connection lasts: events
+// sent before the relay got our CLOSE still come, and the relay's reader
+// blocks on them otherwise.
+func (s *authorSub) forward(sub *nostr.Subscription) {
+	for ev := range sub.Events {
+		s.heard.Store(true)
+		select {
+		case s.Events <- nostr.EventMessage{Event: ev, Relay: sub.Relay.URL}:
+		case <-s.done:
+		}
+	}
+}
+
+// subscribeExtra connects to url and forwards the events matching filters
+// until the subscription is closed.
+func (s *authorSub) subscribeExtra(url string, filters nostr.Filters) {
+	ctx, cancel := context.WithTimeout(context.Background(), extraRelayTimeout)
+	relay, err := nostr.RelayConnectContext(ctx, url)
+	cancel()
+	if err != nil {
+		// extra relays are a bonus; the pool still covers the authors
+		return
+	}
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		relay.Close()
+		return
+	default:
+	}
+	s.relays = append(s.relays, relay)
+	s.mu.Unlock()
+
+	sub := relay.Subscribe(filters)
+	// Keep reading until the connection is gone: the relay's reader blocks
+	// on these unbuffered channels otherwise.
+	for {
+		select {
+		case ev := <-sub.Events:
+			select {
+			case s.Events <- nostr.EventMessage{Event: ev, Relay: relay.URL}:
+			case <-s.done:
+			}
+		case <-sub.EndOfStoredEvents:
+		case <-relay.Notices:
+		case <-relay.ConnectionError:
+			return
+		}
+	}
+}
+
+// closeSubscription asks the relay to end sub. Unlike sub.Unsub it leaves
+// sub.Events open: the relay's reader may be about to send on it, and a
+// send on a closed channel panics. go-nostr doesn't export the ID of a
+// subscription, so it is read by reflection.
+func closeSubscription(sub *nostr.Subscription) {
+	id := reflect.ValueOf(sub).Elem().FieldByName("id").String()
+	sub.Relay.Connection.WriteJSON([]interface{}{"CLOSE", id})
+}
+
+// errNoReply means no relay answered a query, so its result says nothing.
+var errNoReply = errors.New("no relay replied")
+
+// answered tells whether a relay of the pool has replied so far, with an
+// event or with EOSE. An empty result is only "nothing there" if one did.
+func (s *authorSub) answered() bool {
+	if s.heard.Load() {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		select {
+		case <-sub.EndOfStoredEvents:
+			s.eose = true
+		default:
+		}
+	}
+	return s.eose
+}
+
+// Close ends the subscriptions, disconnects from the extra relays and stops
+// forwarding events.
+func (s *authorSub) Close() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		close(s.done)
+		for _, sub := range s.subs {
+			closeSubscription(sub)
+		}
+		for _, relay := range s.relays {
+			relay.Close()
+		}
+		s.mu.Unlock()
+	})
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestAuthorFilters(t *testing.T) {
+	keys := make([]string, 250)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%064x", i)
+	}
+	base := nostr.Filter{Kinds: []int{nostr.KindTextNote}, Limit: 20}
+	tests := []struct {
+		keys  []string
+		sizes []int
+	}{
+		{nil, nil},
+		{keys[:1], []int{1}},
+		{keys[:100], []int{100}},
+		{keys[:101], []int{100, 1}},
+		{keys, []int{100, 100, 50}},
+	}
+	for _, tt := range tests {
+		filters := authorFilters(base, tt.keys)
+		var sizes []int
+		var all []string
+		for _, f := range filters {
+			sizes = append(sizes, len(f.Authors))
+			all = append(all, f.Authors...)
+			if f.Limit != base.Limit || !reflect.DeepEqual(f.Kinds, base.Kinds) {
+				t.Errorf("%d keys: filter %v lost the rest of the base filter", len(tt.keys), f)
+			}
+		}
+		if !reflect.DeepEqual(sizes, tt.sizes) {
+			t.Errorf("%d keys: filters of %v authors, want %v", len(tt.keys), sizes, tt.sizes)
+		}
+		if len(tt.keys) > 0 && !reflect.DeepEqual(all, tt.keys) {
+			t.Errorf("%d keys: authors not kept in order", len(tt.keys))
+		}
+	}
+	if base.Authors != nil {
+		t.Errorf("base filter changed: %v", base)
+	}
+}
+
+func TestPlanExtraRelays(t *testing.T) {
+	defer func(c Config) { config = c }(config)
+	config.DataDir = t.TempDir()
+	config.Relays = map[string]Policy{
+		"wss://ours.example":  {Read: true, Write: true},
+		"wss://write.example": {Write: true},
+	}
+	writes := func(urls ...string) relayList {
+		l := relayList{Relays: make(map[string]Policy), Fetched: time.Now()}
+		for _, url := range urls {
+			l.Relays[url] = Policy{Write: true}
+		}
+		return l
+	}
+	lists := map[string]relayList{
+		"a": writes("wss://ours.example", "wss://x.example"),
+		"b": writes("wss://x.example", "wss://y.example"),
+		"c": writes("wss://x.example"),
+		"d": writes("wss://y.example"),
+		"e": writes("wss://z.example"),
+		"f": writes("wss://write.example"),
+		"g": {Relays: map[string]Policy{"wss://read.example": {Read: true}}, Fetched: time.Now()},
+		"h": {Fetched: time.Now()},
+	}
+	if err := saveDataFile(relayListsFile, lists); err != nil {
+		t.Fatal(err)
+	}
+	config.Following = map[string]Follow{
+		"d": {Key: "d", Relays: []string{"wss://ours.example/"}},
+		"h": {Key: "h", Relays: []string{"wss://z.example"}},
+	}
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	tests := []struct {
+		limit int
+		want  map[string][]string
+	}{
+		{0, map[string][]string{}},
+		// x serves b and c; then z (e, h) wins over the other single ones by name
+		{1, map[string][]string{"wss://x.example": {"b", "c"}}},
+		{2, map[string][]string{
+			"wss://x.example": {"b", "c"},
+			"wss://z.example": {"e", "h"},
+		}},
+		{8, map[string][]string{
+			"wss://x.example":     {"b", "c"},
+			"wss://z.example":     {"e", "h"},
+			"wss://write.example": {"f"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := planExtraRelays(keys, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("limit %d: plan %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+// storedRelay is a relay answering every subscription with those of events
+// its filters match, then EOSE.
+func storedRelay(t *testing.T, events ...nostr.Event) (url string) {
+	server := httptest.NewServer(storedRelayHandler(events...))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func storedRelayHandler(events ...nostr.Event) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg []json.RawMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			var label, id string
+			if len(msg) < 2 || json.Unmarshal(msg[0], &label) != nil || label != "REQ" {
+				continue
+			}
+			json.Unmarshal(msg[1], &id)
+			var filters nostr.Filters
+			for _, raw := range msg[2:] {
+				var f nostr.Filter
+				if json.Unmarshal(raw, &f) == nil {
+					filters = append(filters, f)
+				}
+			}
+			for _, ev := range events {
+				if filters.Match(&ev) {
+					conn.WriteJSON([]interface{}{"EVENT", id, ev})
+				}
+			}
+			conn.WriteJSON([]interface{}{"EOSE", id})
+		}
+	})
+}
+
+// testPool makes a pool of the relays at urls the one used until the test
+// ends.
+func testPool(t *testing.T, urls ...string) {
+	old := pool
+	t.Cleanup(func() { pool = old })
+	pool = nostr.NewRelayPool()
+	for _, url := range urls {
+		if err := <-pool.Add(url, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// signedTestEvent signs ev with testPrivateKey.
+func signedTestEvent(t *testing.T, ev nostr.Event) nostr.Event {
+	t.Helper()
+	ev.PubKey = getPubKey(testPrivateKey)
+	if ev.Tags == nil {
+		ev.Tags = nostr.Tags{}
+	}
+	if err := ev.Sign(hex.EncodeToString([]byte(testPrivateKey))); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+// floodRelay is a relay sending ev to every subscription every millisecond,
+// and for a while longer after the subscription is closed, as events on
+// their way do. closed gets the ID of each subscription closed.
+func floodRelay(t *testing.T, ev nostr.Event) (url string, closed chan string) {
+	closed = make(chan string, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var mu sync.Mutex
+		stops := make(map[string]chan struct{})
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg []json.RawMessage
+			var label, id string
+			if json.Unmarshal(message, &msg) != nil || len(msg) < 2 {
+				continue
+			}
+			json.Unmarshal(msg[0], &label)
+			json.Unmarshal(msg[1], &id)
+			switch label {
+			case "REQ":
+				stop := make(chan struct{})
+				stops[id] = stop
+				go func() {
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						mu.Lock()
+						err := conn.WriteJSON([]interface{}{"EVENT", id, ev})
+						mu.Unlock()
+						if err != nil {
+							return
+						}
+						time.Sleep(time.Millisecond)
+					}
+				}()
+			case "CLOSE":
+				if stop, ok := stops[id]; ok {
+					time.AfterFunc(50*time.Millisecond, func() { close(stop) })
+					delete(stops, id)
+					closed <- id
+				}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), closed
+}
+
+func TestAuthorSubCloseWhileReceiving(t *testing.T) {
+	ev := signedTestEvent(t, nostr.Event{CreatedAt: time.Unix(1700000000, 0), Kind: nostr.KindTextNote, Content: "again"})
+	url, closed := floodRelay(t, ev)
+	testPool(t, url)
+	// closing used to close the channel the relay's reader sends on
+	for i := 0; i < 20; i++ {
+		sub := subscribePool(nostr.Filters{{Kinds: []int{nostr.KindTextNote}}})
+		select {
+		case <-sub.Events:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: no event", i)
+		}
+		sub.Close()
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: the relay got no CLOSE", i)
+		}
+	}
+}
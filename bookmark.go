@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	KindPinList      = 10001 // NIP-51
+	KindBookmarkList = 10003 // NIP-51
+)
+
+// bookmarkSpec is the bookmark list: notes ("e") and, from other clients,
+// articles ("a"), hashtags ("t") and URLs ("r").
+var bookmarkSpec = listSpec{
+	Kind:  KindBookmarkList,
+	File:  "bookmarks.json",
+	Types: []string{"e", "a", "t", "r"},
+	Normalize: func(item listItem) listItem {
+		if item.Type == "t" {
+			item.Value = normalizeHashtag(item.Value)
+		}
+		return item
+	},
+}
+
+// pinSpec is the list of our own notes shown at the top of our profile.
+var pinSpec = listSpec{
+	Kind:  KindPinList,
+	File:  "pins.json",
+	Types: []string{"e"},
+}
+
+// fetchEvents returns the events with the given ids, in the order of ids.
+// Events no relay has are left out. The pool must be initialized.
+func fetchEvents(ids []string) []nostr.Event {
+	if len(ids) == 0 {
+		return nil
+	}
+	found := make(map[string]nostr.Event)
+	sub := subscribePool(nostr.Filters{{IDs: ids}})
+	defer sub.Close()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+		found[ev.ID] = ev
+		if len(found) == len(ids) {
+			break
+		}
+	}
+	var events []nostr.Event
+	for _, id := range ids {
+		if ev, ok := found[id]; ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// listEventIDs returns the ids of the notes in list.
+func listEventIDs(list nip51List) []string {
+	var ids []string
+	for _, item := range list.Items {
+		if item.Type == "e" {
+			ids = append(ids, item.Value)
+		}
+	}
+	return ids
+}
+
+// fetchPins returns the notes pubkey pinned, newest pin first.
+// The pool must be initialized.
+func fetchPins(pubkey string) []nostr.Event {
+	ev, ok := fetchNewest([]string{pubkey}, KindPinList)[pubkey]
+	if !ok {
+		return nil
+	}
+	list, _ := pinSpec.parse(&ev)
+	ids := listEventIDs(list)
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	var pinned []nostr.Event
+	for _, note := range fetchEvents(ids) {
+		// a pin list can only vouch for its author's notes
+		if note.PubKey == pubkey {
+			pinned = append(pinned, note)
+		}
+	}
+	return pinned
+}
+
+// pinNote adds our note id to the pin list or removes it.
+// The pool must be initialized.
+func pinNote(id string, pin bool) (*nostr.Event, chan deliveryStatus, error) {
+	if pin {
+		notes := fetchEvents([]string{id})
+		if len(notes) == 0 {
+			return nil, nil, errors.New("note not found")
+		}
+		if notes[0].PubKey != getPubKey(config.PrivateKey) {
+			return nil, nil, errors.New("only your own notes can be pinned")
+		}
+	}
+	_, event, statuses, err := pinSpec.update([]listItem{{Type: "e", Value: id}}, pin)
+	return event, statuses, err
+}
+
+func bookmarks(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Println("No private key set.")
+		return
+	}
+	initNostr()
+
+	if opts["add"].(bool) || opts["remove"].(bool) {
+		id, err := eventIDArg(opts["<id>"].(string))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		private, _ := opts.Bool("--private")
+		add := opts["add"].(bool)
+		_, event, statuses, err := bookmarkSpec.update([]listItem{{Type: "e", Value: id, Private: private}}, add)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if add {
+			fmt.Printf("Bookmarked %s.\n", id)
+		} else {
+			fmt.Printf("Removed bookmark %s.\n", id)
+		}
+		printPublishStatus(event, statuses)
+		return
+	}
+
+	verbose, _ := opts.Bool("--verbose")
+	jsonformat, _ := opts.Bool("--json")
+	list, err := bookmarkSpec.sync()
+	if err != nil {
+		log.Println(err)
+	}
+	if len(list.Items) == 0 {
+		fmt.Println("No bookmarks.")
+		return
+	}
+	notes := make(map[string]nostr.Event)
+	for _, note := range fetchEvents(listEventIDs(list)) {
+		notes[note.ID] = note
+	}
+	for _, item := range list.Items {
+		if item.Private && !jsonformat {
+			fmt.Print("(private) ")
+		}
+		note, ok := notes[item.Value]
+		if item.Type != "e" || !ok {
+			fmt.Println(item)
+			continue
+		}
+		printEvent(note, nil, verbose, jsonformat)
+	}
+}
+
+func pin(opts docopt.Opts, pinning bool) {
+	if config.PrivateKey == "" {
+		log.Println("No private key set.")
+		return
+	}
+	initNostr()
+
+	id, err := eventIDArg(opts["<id>"].(string))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	event, statuses, err := pinNote(id, pinning)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if pinning {
+		fmt.Printf("Pinned %s.\n", id)
+	} else {
+		fmt.Printf("Unpinned %s.\n", id)
+	}
+	printPublishStatus(event, statuses)
+}
+
+func showPins(opts docopt.Opts) {
+	pubkey := ""
+	if arg, ok := opts["<pubkey>"].(string); ok && arg != "" {
+		pubkey = nip19.TranslatePublicKey(arg)
+	} else if config.PrivateKey != "" {
+		pubkey = getPubKey(config.PrivateKey)
+	}
+	if pubkey == "" {
+		log.Println("Invalid pubkey.")
+		return
+	}
+	initNostr()
+
+	pinned := fetchPins(pubkey)
+	if len(pinned) == 0 {
+		fmt.Println("No pinned notes.")
+		return
+	}
+	verbose, _ := opts.Bool("--verbose")
+	jsonformat, _ := opts.Bool("--json")
+	for _, note := range pinned {
+		printEvent(note, nil, verbose, jsonformat)
+	}
+}
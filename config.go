@@ -8,6 +8,7 @@ type Config struct {
 	Following        map[string]Follow `json:"following,flow"`
 	PrivateKey       string            `json:"privatekey,omitempty"`
 	AllowImageASCII  bool              `json:"allow_image_ascii,omitempty"`
+	Feeds            []Feed            `json:"feeds"`
 }
 
 type Follow struct {
@@ -39,4 +40,7 @@ func (c *Config) Init() {
 	if c.Following == nil {
 		c.Following = make(map[string]Follow)
 	}
+	if c.Feeds == nil {
+		c.Feeds = defaultFeeds()
+	}
 }
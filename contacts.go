@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// contactDiff compares the local follows with a remote contact list.
+type contactDiff struct {
+	LocalOnly  []string
+	RemoteOnly []string
+	Both       []string
+}
+
+// fetchContactList returns our newest kind-3 event, or nil if no relay has one.
+// It fails with errNoReply when no relay replied. The pool must be initialized.
+func fetchContactList(pubkey string) (*nostr.Event, error) {
+	var newest *nostr.Event
+	sub := subscribePool(nostr.Filters{{Authors: []string{pubkey}, Kinds: []int{nostr.KindContactList}}})
+	defer sub.Close()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+		if ev.Kind != nostr.KindContactList || ev.PubKey != pubkey {
+			continue
+		}
+		if newest == nil || ev.CreatedAt.After(newest.CreatedAt) {
+			ev := ev
+			newest = &ev
+		}
+	}
+	if !sub.answered() {
+		return nil, errNoReply
+	}
+	return newest, nil
+}
+
+// parseContactList reads the p-tags of a kind-3 event with their relay hints
+// and petnames. NIP-02.
+func parseContactList(ev *nostr.Event) map[string]Follow {
+	follows := make(map[string]Follow)
+	if ev == nil {
+		return follows
+	}
+	for _, tag := range ev.Tags.GetAll([]string{"p", ""}) {
+		key := tag.Value()
+		if len(key) != 64 {
+			continue
+		}
+		f := Follow{Key: key}
+		if len(tag) > 2 && tag[2] != "" {
+			f.Relays = []string{tag[2]}
+		}
+		if len(tag) > 3 {
+			f.Name = tag[3]
+		}
+		follows[key] = f
+	}
+	return follows
+}
+
+// contactListTags turns follows into NIP-02 p-tags, sorted by key.
+func contactListTags(follows map[string]Follow) nostr.Tags {
+	keys := make([]string, 0, len(follows))
+	for key := range follows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var tags nostr.Tags
+	for _, key := range keys {
+		follow := follows[key]
+		relay := ""
+		if len(follow.Relays) > 0 {
+			relay = follow.Relays[0]
+		}
+		tags = append(tags, nostr.Tag{"p", follow.Key, relay, follow.Name})
+	}
+	return tags
+}
+
+func diffContacts(local, remote map[string]Follow) contactDiff {
+	var d contactDiff
+	for key := range local {
+		if _, ok := remote[key]; ok {
+			d.Both = append(d.Both, key)
+		} else {
+			d.LocalOnly = append(d.LocalOnly, key)
+		}
+	}
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			d.RemoteOnly = append(d.RemoteOnly, key)
+		}
+	}
+	sort.Strings(d.LocalOnly)
+	sort.Strings(d.RemoteOnly)
+	sort.Strings(d.Both)
+	return d
+}
+
+// mergeFollow combines two entries for the same key: the preferred name wins
+// when set and relay hints are joined.
+func mergeFollow(preferred, other Follow) Follow {
+	f := preferred
+	if f.Name == "" {
+		f.Name = other.Name
+	}
+	seen := make(map[string]bool)
+	var relays []string
+	for _, url := range append(append([]string{}, preferred.Relays...), other.Relays...) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			relays = append(relays, url)
+		}
+	}
+	f.Relays = relays
+	return f
+}
+
+// pullContacts replaces the local follows with the remote list, keeping local
+// names and relay hints where the remote list has none.
+func pullContacts(remote map[string]Follow) {
+	follows := make(map[string]Follow, len(remote))
+	for key, f := range remote {
+		follows[key] = mergeFollow(f, config.Following[key])
+	}
+	config.Following = follows
+}
+
+// mergeContacts adds the remote follows to the local ones.
+func mergeContacts(remote map[string]Follow) {
+	for key, f := range remote {
+		if local, ok := config.Following[key]; ok {
+			config.Following[key] = mergeFollow(local, f)
+		} else {
+			config.Following[key] = f
+		}
+	}
+}
+
+// pushContacts publishes the local follows as our kind-3 list. content is
+// carried over from the previous list, where some clients keep their relays.
+func pushContacts(content string) (*nostr.Event, chan deliveryStatus, error) {
+	if config.PrivateKey == "" {
+		return nil, nil, errors.New("private key not set")
+	}
+	return publishEvent(&nostr.Event{
+		CreatedAt: time.Now(),
+		Kind:      nostr.KindContactList,
+		Tags:      contactListTags(config.Following),
+		Content:   content,
+	})
+}
+
+func followLabel(f Follow) string {
+	if f.Name != "" {
+		return f.Key + " " + f.Name
+	}
+	return f.Key
+}
+
+func syncFollowing(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Printf("No private key set.\n")
+		return
+	}
+
+	initNostr()
+
+	remoteEvent, fetchErr := fetchContactList(getPubKey(config.PrivateKey))
+	remote := parseContactList(remoteEvent)
+	d := diffContacts(config.Following, remote)
+
+	if fetchErr != nil {
+		fmt.Printf("Can't tell what contact list the relays have: %s.\n", fetchErr)
+	} else if remoteEvent == nil {
+		fmt.Println("No contact list found on the relays.")
+	} else {
+		fmt.Printf("Contact list from %s: %d follows.\n", humanize.Time(remoteEvent.CreatedAt), len(remote))
+	}
+	fmt.Printf("Both: %d\n", len(d.Both))
+	fmt.Printf("Local only: %d\n", len(d.LocalOnly))
+	for _, key := range d.LocalOnly {
+		fmt.Println("  < " + followLabel(config.Following[key]))
+	}
+	fmt.Printf("Remote only: %d\n", len(d.RemoteOnly))
+	for _, key := range d.RemoteOnly {
+		fmt.Println("  > " + followLabel(remote[key]))
+	}
+
+	content := ""
+	if remoteEvent != nil {
+		content = remoteEvent.Content
+	}
+	pull, _ := opts.Bool("--pull")
+	push, _ := opts.Bool("--push")
+	merge, _ := opts.Bool("--merge")
+	force, _ := opts.Bool("--force")
+	if (push || merge) && fetchErr != nil && !force {
+		// a newer list may be out there that this would replace
+		log.Println("Not publishing without knowing the remote list; use --force to publish anyway.")
+		return
+	}
+	switch {
+	case pull:
+		if remoteEvent == nil {
+			log.Println("Nothing to pull.")
+			return
+		}
+		pullContacts(remote)
+		fmt.Printf("Now following %d.\n", len(config.Following))
+	case merge:
+		mergeContacts(remote)
+		fmt.Printf("Now following %d.\n", len(config.Following))
+		fallthrough
+	case push:
+		if len(config.Following) == 0 {
+			log.Printf("Contact list empty.\n")
+			return
+		}
+		event, statuses, err := pushContacts(content)
+		if err != nil {
+			log.Printf("Error publishing: %s.\n", err.Error())
+			return
+		}
+		printPublishStatus(event, statuses)
+	default:
+		if len(d.LocalOnly) > 0 || len(d.RemoteOnly) > 0 {
+			fmt.Println("Run with --pull, --push or --merge to reconcile.")
+		}
+	}
+}
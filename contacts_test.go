@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffContacts(t *testing.T) {
+	follows := func(keys ...string) map[string]Follow {
+		m := make(map[string]Follow)
+		for _, key := range keys {
+			m[key] = Follow{Key: key}
+		}
+		return m
+	}
+	tests := []struct {
+		local, remote map[string]Follow
+		want          contactDiff
+	}{
+		{nil, nil, contactDiff{}},
+		{follows("b", "a"), nil, contactDiff{LocalOnly: []string{"a", "b"}}},
+		{nil, follows("a"), contactDiff{RemoteOnly: []string{"a"}}},
+		{follows("a", "b"), follows("b", "a"), contactDiff{Both: []string{"a", "b"}}},
+		{
+			follows("c", "a", "d"), follows("d", "b", "a", "e"),
+			contactDiff{LocalOnly: []string{"c"}, RemoteOnly: []string{"b", "e"}, Both: []string{"a", "d"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := diffContacts(tt.local, tt.remote); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diffContacts(%v, %v) = %+v, want %+v", tt.local, tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestMergeFollow(t *testing.T) {
+	tests := []struct {
+		preferred, other, want Follow
+	}{
+		{Follow{Key: "a"}, Follow{Key: "a"}, Follow{Key: "a"}},
+		{Follow{Key: "a", Name: "alice"}, Follow{Key: "a", Name: "al"}, Follow{Key: "a", Name: "alice"}},
+		{Follow{Key: "a"}, Follow{Key: "a", Name: "al"}, Follow{Key: "a", Name: "al"}},
+		{
+			Follow{Key: "a", Relays: []string{"wss://x", ""}},
+			Follow{Key: "a", Relays: []string{"wss://y", "wss://x"}},
+			Follow{Key: "a", Relays: []string{"wss://x", "wss://y"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := mergeFollow(tt.preferred, tt.other); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeFollow(%+v, %+v) = %+v, want %+v", tt.preferred, tt.other, got, tt.want)
+		}
+	}
+}
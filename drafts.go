@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sort"
+	"time"
+)
+
+const draftsFile = "drafts.json"
+
+// Draft is an unpublished note kept in the datadir. Reply drafts remember
+// the NIP-10 thread they belong to.
+type Draft struct {
+	ID            string    `json:"id"`
+	Content       string    `json:"content"`
+	ReplyTo       string    `json:"reply_to,omitempty"`
+	ReplyToAuthor string    `json:"reply_to_author,omitempty"`
+	RootID        string    `json:"root_id,omitempty"`
+	RootAuthor    string    `json:"root_author,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// randomID returns a short random hex identifier for local records.
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// loadDrafts returns all drafts, most recently edited first.
+func loadDrafts() []Draft {
+	var drafts []Draft
+	if err := loadDataFile(draftsFile, &drafts); err != nil {
+		log.Printf("can't read %s: %s", draftsFile, err)
+	}
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].UpdatedAt.After(drafts[j].UpdatedAt)
+	})
+	return drafts
+}
+
+// saveDraft inserts or updates d (matched by ID) and returns it with its ID set.
+func saveDraft(d Draft) (Draft, error) {
+	if d.ID == "" {
+		d.ID = randomID()
+	}
+	d.UpdatedAt = time.Now()
+	drafts := loadDrafts()
+	replaced := false
+	for i := range drafts {
+		if drafts[i].ID == d.ID {
+			drafts[i] = d
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		drafts = append(drafts, d)
+	}
+	return d, saveDataFile(draftsFile, drafts)
+}
+
+// deleteDraft removes the draft with the given ID, if any.
+func deleteDraft(id string) error {
+	drafts := loadDrafts()
+	for i := range drafts {
+		if drafts[i].ID == id {
+			drafts = append(drafts[:i], drafts[i+1:]...)
+			return saveDataFile(draftsFile, drafts)
+		}
+	}
+	return nil
+}
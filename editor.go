@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editorScissors separates the draft from the help text in the editor
+// template; everything from this line on is dropped when reading it back.
+const editorScissors = "# ------------------------ >8 ------------------------"
+
+// editorCommand returns the command for $VISUAL or $EDITOR (vi if neither is set)
+// opening path. The editor value may contain arguments, e.g. "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	args = append(args, path)
+	return exec.Command(args[0], args[1:]...)
+}
+
+// writeEditorFile writes draft followed by the scissors line and the help
+// lines to a temporary file and returns its path.
+func writeEditorFile(draft string, help ...string) (string, error) {
+	f, err := os.CreateTemp("", "noscl-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	b.WriteString(draft)
+	if draft != "" && !strings.HasSuffix(draft, "\n") {
+		b.WriteByte('\n')
+	}
+	b.WriteString("\n" + editorScissors + "\n")
+	b.WriteString("# Do not modify or remove the line above.\n")
+	b.WriteString("# Everything below it is ignored; an empty note aborts.\n")
+	for _, line := range help {
+		for _, l := range strings.Split(line, "\n") {
+			b.WriteString("# " + l + "\n")
+		}
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// readEditorFile reads back a file written by writeEditorFile, removes it and
+// returns the content above the scissors line without trailing whitespace.
+func readEditorFile(path string) (string, error) {
+	defer os.Remove(path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	content := string(b)
+	if i := strings.Index(content, editorScissors); i >= 0 {
+		content = content[:i]
+	}
+	return strings.TrimRight(content, " \t\r\n"), nil
+}
+
+// editContent opens draft in the user's editor attached to the terminal and
+// returns the edited content.
+func editContent(draft string, help ...string) (string, error) {
+	path, err := writeEditorFile(draft, help...)
+	if err != nil {
+		return "", err
+	}
+	cmd := editorCommand(path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return readEditorFile(path)
+}
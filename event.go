@@ -20,8 +20,9 @@ func viewEvent(opts docopt.Opts) {
 	}
 	initNostr()
 
-	_, all := pool.Sub(nostr.Filters{{IDs: []string{id}}})
-	for event := range nostr.Unique(all) {
+	sub := subscribePool(nostr.Filters{{IDs: []string{id}}})
+	defer sub.Close()
+	for event := range nostr.Unique(sub.Events) {
 		if event.ID != id {
 			log.Printf("got unexpected event %s.\n", event.ID)
 			continue
@@ -40,15 +41,13 @@ func viewEvent(opts docopt.Opts) {
 }
 
 func deleteEvent(opts docopt.Opts) {
-	initNostr()
-
 	id := opts["<id>"].(string)
 	if id == "" {
 		log.Println("Event id is empty! Exiting.")
 		return
 	}
 
-	event, statuses, err := pool.PublishEvent(&nostr.Event{
+	event, statuses, err := publishEvent(&nostr.Event{
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindDeletion,
 		Tags:      nostr.Tags{nostr.Tag{"e", id}},
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	eventCacheFile  = "events.json"
+	maxCachedEvents = 3000
+	KindLongForm    = 30023 // NIP-23 articles
+
+	// how long events arriving live wait to be written with others
+	eventCacheSaveDelay = 30 * time.Second
+)
+
+// the text events we have seen, newest first, and the search index over them
+var eventCache struct {
+	sync.Mutex
+	dir    string
+	events []nostr.Event
+	ids    map[string]bool
+	index  *searchIndex
+	dirty  bool        // changed since written
+	save   *time.Timer // pending write of cacheEventsLater
+}
+
+func loadEventCacheLocked() {
+	if eventCache.events != nil && eventCache.dir == config.DataDir {
+		return
+	}
+	var events []nostr.Event
+	if err := loadDataFile(eventCacheFile, &events); err != nil {
+		log.Printf("Can't read %s: %s.\n", eventCacheFile, err)
+	}
+	if events == nil {
+		events = []nostr.Event{}
+	}
+	eventCache.dir = config.DataDir
+	eventCache.events = events
+	eventCache.ids = make(map[string]bool, len(events))
+	for _, ev := range events {
+		eventCache.ids[ev.ID] = true
+	}
+	eventCache.index = nil
+	eventCache.dirty = false
+	if eventCache.save != nil {
+		eventCache.save.Stop()
+		eventCache.save = nil
+	}
+}
+
+// cacheEvents keeps the notes and articles among events for local search.
+func cacheEvents(events []nostr.Event) {
+	eventCache.Lock()
+	defer eventCache.Unlock()
+	loadEventCacheLocked()
+	if addEventsLocked(events) {
+		saveEventCacheLocked()
+	}
+}
+
+// cacheEventsLater is cacheEvents for events arriving one by one: it writes
+// them together with the others coming within eventCacheSaveDelay.
+func cacheEventsLater(events []nostr.Event) {
+	eventCache.Lock()
+	defer eventCache.Unlock()
+	loadEventCacheLocked()
+	if !addEventsLocked(events) {
+		return
+	}
+	eventCache.dirty = true
+	if eventCache.save == nil {
+		eventCache.save = time.AfterFunc(eventCacheSaveDelay, saveEventCache)
+	}
+}
+
+// saveEventCache writes what cacheEventsLater hasn't written yet.
+func saveEventCache() {
+	eventCache.Lock()
+	defer eventCache.Unlock()
+	if eventCache.dirty {
+		saveEventCacheLocked()
+	}
+}
+
+func saveEventCacheLocked() {
+	if eventCache.save != nil {
+		eventCache.save.Stop()
+		eventCache.save = nil
+	}
+	eventCache.dirty = false
+	if err := saveDataFile(eventCacheFile, eventCache.events); err != nil {
+		log.Printf("Can't write %s: %s.\n", eventCacheFile, err)
+	}
+}
+
+// addEventsLocked merges the notes and articles among events not cached
+// yet into the cache and tells whether there were any.
+func addEventsLocked(events []nostr.Event) bool {
+	var fresh []nostr.Event
+	for _, ev := range events {
+		if eventCache.ids[ev.ID] || (ev.Kind != nostr.KindTextNote && ev.Kind != KindLongForm) {
+			continue
+		}
+		eventCache.ids[ev.ID] = true
+		fresh = append(fresh, ev)
+	}
+	if len(fresh) == 0 {
+		return false
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		return fresh[i].CreatedAt.After(fresh[j].CreatedAt)
+	})
+	// both are newest first
+	old := eventCache.events
+	merged := make([]nostr.Event, 0, len(old)+len(fresh))
+	for len(old) > 0 || len(fresh) > 0 {
+		if len(fresh) == 0 || (len(old) > 0 && !fresh[0].CreatedAt.After(old[0].CreatedAt)) {
+			merged = append(merged, old[0])
+			old = old[1:]
+		} else {
+			merged = append(merged, fresh[0])
+			fresh = fresh[1:]
+		}
+	}
+	if len(merged) > maxCachedEvents {
+		for _, ev := range merged[maxCachedEvents:] {
+			delete(eventCache.ids, ev.ID)
+		}
+		merged = merged[:maxCachedEvents]
+	}
+	eventCache.events = merged
+	eventCache.index = nil
+	return true
+}
+
+// localIndex returns the search index over the cached events, building it
+// when the cache changed.
+func localIndex() *searchIndex {
+	eventCache.Lock()
+	defer eventCache.Unlock()
+	loadEventCacheLocked()
+	if eventCache.index == nil {
+		eventCache.index = newSearchIndex(eventCache.events)
+	}
+	return eventCache.index
+}
+
+// searchIndex is an inverted index from words to the events containing them.
+type searchIndex struct {
+	events   []nostr.Event
+	postings map[string][]posting
+}
+
+type posting struct {
+	doc   int // index into events
+	count int // occurrences of the word
+}
+
+// tokenize splits text into lowercased words of at least two letters or
+// digits; # and @ prefixes and punctuation are dropped.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var tokens []string
+	for _, word := range words {
+		if len([]rune(word)) >= 2 {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+func newSearchIndex(events []nostr.Event) *searchIndex {
+	idx := &searchIndex{events: events, postings: make(map[string][]posting)}
+	for doc, ev := range events {
+		counts := make(map[string]int)
+		for _, token := range tokenize(ev.Content) {
+			counts[token]++
+		}
+		for token, count := range counts {
+			idx.postings[token] = append(idx.postings[token], posting{doc, count})
+		}
+	}
+	return idx
+}
+
+// idf weighs rare words higher than common ones.
+func (idx *searchIndex) idf(token string) float64 {
+	n := float64(len(idx.events))
+	return math.Log(1 + (n+1)/float64(len(idx.postings[token])+1))
+}
+
+// score rates how well ev matches the query words.
+func (idx *searchIndex) score(ev nostr.Event, tokens []string) float64 {
+	counts := make(map[string]int)
+	words := tokenize(ev.Content)
+	for _, word := range words {
+		counts[word]++
+	}
+	score := 0.0
+	for _, token := range tokens {
+		if counts[token] > 0 {
+			// dampen long notes repeating a word
+			score += (1 + math.Log(float64(counts[token]))) * idx.idf(token)
+		}
+	}
+	return score / math.Sqrt(float64(len(words)+1))
+}
+
+// search returns the events containing every query word that q allows.
+func (idx *searchIndex) search(q searchQuery) []nostr.Event {
+	tokens := tokenize(q.Text)
+	if len(tokens) == 0 {
+		return nil
+	}
+	var matches map[int]bool
+	for _, token := range tokens {
+		docs := make(map[int]bool)
+		for _, p := range idx.postings[token] {
+			if matches == nil || matches[p.doc] {
+				docs[p.doc] = true
+			}
+		}
+		matches = docs
+		if len(matches) == 0 {
+			return nil
+		}
+	}
+	var events []nostr.Event
+	for doc := range matches {
+		if ev := idx.events[doc]; q.allows(ev) {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
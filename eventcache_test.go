@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"#Nostr and @alice", []string{"nostr", "and", "alice"}},
+		{"a b c go", []string{"go"}},
+		{"über-cool Café 42", []string{"über", "cool", "café", "42"}},
+		{"https://example.com/x?y=1", []string{"https", "example", "com"}},
+		{"日本語 テキスト", []string{"日本語", "テキスト"}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func textNote(id, content string) nostr.Event {
+	return nostr.Event{ID: id, Kind: nostr.KindTextNote, Content: content}
+}
+
+func TestSearchIndexSearch(t *testing.T) {
+	article := textNote("article", "Relays and more relays")
+	article.Kind = KindLongForm
+	bob := textNote("bob", "nostr relays by bob")
+	bob.PubKey = "bob"
+	bob.CreatedAt = time.Unix(2000, 0)
+	idx := newSearchIndex([]nostr.Event{
+		textNote("a", "Nostr relays are great"),
+		textNote("b", "I like nostr"),
+		textNote("c", "relays, relays, RELAYS"),
+		textNote("d", "#nostr #relays"),
+		article,
+		bob,
+		{ID: "dm", Kind: nostr.KindEncryptedDirectMessage, Content: "nostr relays"},
+	})
+	tests := []struct {
+		q    searchQuery
+		want []string
+	}{
+		{searchQuery{Text: "nostr"}, []string{"a", "b", "bob", "d"}},
+		// every word must be there
+		{searchQuery{Text: "Nostr, relays!"}, []string{"a", "bob", "d"}},
+		{searchQuery{Text: "relays nostr great"}, []string{"a"}},
+		{searchQuery{Text: "nostr bitcoin"}, nil},
+		{searchQuery{Text: "relays"}, []string{"a", "article", "bob", "c", "d"}},
+		{searchQuery{Text: "relays", Kinds: []int{KindLongForm}}, []string{"article"}},
+		{searchQuery{Text: "relays", Author: "bob"}, []string{"bob"}},
+		{searchQuery{Text: "relays", Since: time.Unix(1000, 0)}, []string{"bob"}},
+		{searchQuery{Text: "nostr relays", Kinds: []int{nostr.KindEncryptedDirectMessage}}, []string{"dm"}},
+		// too short to be a word
+		{searchQuery{Text: "a"}, nil},
+		{searchQuery{Text: "rel"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, ev := range idx.search(tt.q) {
+			got = append(got, ev.ID)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search(%+v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndexScore(t *testing.T) {
+	events := []nostr.Event{
+		textNote("once", "go is fun to write"),
+		textNote("twice", "go go is fun to write"),
+		textNote("long", "go is fun to write and read and talk about for hours"),
+		textNote("rare", "gopher is fun to write"),
+		textNote("none", "rust is fun to write"),
+		textNote("filler1", "go and more go"),
+		textNote("filler2", "go on"),
+	}
+	idx := newSearchIndex(events)
+	score := func(id string, query string) float64 {
+		for _, ev := range events {
+			if ev.ID == id {
+				return idx.score(ev, tokenize(query))
+			}
+		}
+		t.Fatalf("no event %s", id)
+		return 0
+	}
+	if s := score("none", "go"); s != 0 {
+		t.Errorf("score without the word = %v", s)
+	}
+	tests := []struct {
+		note          string
+		better, worse float64
+	}{
+		{"repeated word", score("twice", "go"), score("once", "go")},
+		{"short note", score("once", "go"), score("long", "go")},
+		{"rare word", score("rare", "gopher"), score("once", "go")},
+		{"more words matched", score("once", "go fun"), score("once", "go")},
+	}
+	for _, tt := range tests {
+		if tt.better <= tt.worse {
+			t.Errorf("%s: %v not above %v", tt.note, tt.better, tt.worse)
+		}
+	}
+	// repeats are dampened
+	if twice, once := score("twice", "go"), score("once", "go"); twice >= 2*once {
+		t.Errorf("a repeated word counts %v, once %v", twice, once)
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	defer func(c Config) { config = c }(config)
+	config.DataDir = t.TempDir()
+
+	signed := func(content string, unix int64) nostr.Event {
+		return signedTestEvent(t, nostr.Event{Kind: nostr.KindTextNote, Content: content, CreatedAt: time.Unix(unix, 0)})
+	}
+	localOnly := signed("nostr relays are here", 100)
+	both := signed("nostr relays, nostr relays", 200)
+	remoteOnly := signed("a long note mentioning nostr and also relays among many other words", 300)
+	cacheEvents([]nostr.Event{localOnly, both, signed("nothing to see", 400)})
+
+	relay := storedRelayHandler(both, remoteOnly)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "application/nostr+json" {
+			fmt.Fprint(w, `{"supported_nips":[1,11,50]}`)
+			return
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	config.Relays = map[string]Policy{url: {Read: true}}
+
+	results, relays := runSearch(searchQuery{Text: "nostr relays"})
+	if !reflect.DeepEqual(relays, []string{url}) {
+		t.Fatalf("asked %v, want %s", relays, url)
+	}
+	type result struct {
+		id     string
+		local  bool
+		relays int
+	}
+	var got []result
+	for i, r := range results {
+		got = append(got, result{r.Event.ID, r.Local, len(r.Relays)})
+		if i > 0 && r.Score > results[i-1].Score {
+			t.Errorf("result %d scores %v, above the one before it", i, r.Score)
+		}
+	}
+	// both has the words twice and a relay ranked it; the long remote
+	// note comes last
+	want := []result{{both.ID, true, 1}, {localOnly.ID, true, 0}, {remoteOnly.ID, false, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results %v, want %v", got, want)
+	}
+	// what the relays found is searched locally next time
+	var cached []string
+	for _, ev := range localIndex().search(searchQuery{Text: "nostr relays"}) {
+		cached = append(cached, ev.ID)
+	}
+	sort.Strings(cached)
+	wantCached := []string{localOnly.ID, both.ID, remoteOnly.ID}
+	sort.Strings(wantCached)
+	if !reflect.DeepEqual(cached, wantCached) {
+		t.Errorf("cached %v, want %v", cached, wantCached)
+	}
+}
+
+func TestCacheEvents(t *testing.T) {
+	defer func(dir string) { config.DataDir = dir }(config.DataDir)
+	config.DataDir = t.TempDir()
+	at := func(id string, unix int64) nostr.Event {
+		ev := textNote(id, "note "+id)
+		ev.CreatedAt = time.Unix(unix, 0)
+		return ev
+	}
+	ids := func() []string {
+		eventCache.Lock()
+		defer eventCache.Unlock()
+		var ids []string
+		for _, ev := range eventCache.events {
+			ids = append(ids, ev.ID)
+		}
+		return ids
+	}
+	saved := func() []string {
+		var events []nostr.Event
+		if err := loadDataFile(eventCacheFile, &events); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, ev := range events {
+			ids = append(ids, ev.ID)
+		}
+		return ids
+	}
+	path := filepath.Join(config.DataDir, eventCacheFile)
+
+	metadata := at("meta", 500)
+	metadata.Kind = nostr.KindSetMetadata
+	cacheEvents([]nostr.Event{at("b", 200), at("d", 400), metadata})
+	cacheEvents([]nostr.Event{at("a", 100), at("c", 300), at("d", 400), at("e", 500)})
+	want := []string{"e", "d", "c", "b", "a"}
+	if got := ids(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached %v, want %v", got, want)
+	}
+	if got := saved(); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+
+	// nothing new: nothing written
+	os.Remove(path)
+	cacheEvents([]nostr.Event{at("c", 300), metadata})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("rewrote the cache without a change: %v", err)
+	}
+
+	// live events are kept at once but written later, together
+	cacheEventsLater([]nostr.Event{at("f", 600)})
+	cacheEventsLater([]nostr.Event{at("g", 50)})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("wrote live events right away: %v", err)
+	}
+	want = []string{"f", "e", "d", "c", "b", "a", "g"}
+	if got := ids(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached %v, want %v", got, want)
+	}
+	saveEventCache()
+	if got := saved(); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+	eventCache.Lock()
+	pending := eventCache.save != nil
+	eventCache.Unlock()
+	if pending {
+		t.Errorf("a write is still pending after saving")
+	}
+}
+
+func TestCacheEventsLimit(t *testing.T) {
+	defer func(dir string) { config.DataDir = dir }(config.DataDir)
+	config.DataDir = t.TempDir()
+	events := make([]nostr.Event, maxCachedEvents+10)
+	for i := range events {
+		events[i] = textNote(fmt.Sprint(i), "")
+		events[i].CreatedAt = time.Unix(int64(i), 0)
+	}
+	cacheEvents(events)
+	eventCache.Lock()
+	n, newest, oldest := len(eventCache.events), eventCache.events[0].ID, eventCache.events[len(eventCache.events)-1].ID
+	eventCache.Unlock()
+	if n != maxCachedEvents || newest != fmt.Sprint(maxCachedEvents+9) || oldest != "10" {
+		t.Errorf("kept %d events from %s to %s", n, newest, oldest)
+	}
+	// the dropped ones aren't remembered as cached
+	cacheEvents([]nostr.Event{events[0]})
+	eventCache.Lock()
+	n = len(eventCache.events)
+	eventCache.Unlock()
+	if n != maxCachedEvents {
+		t.Errorf("%d events cached", n)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// Feed is a named selection of events, shown as an entry of the TUI menu
+// and available as 'home --feed=<name>'.
+type Feed struct {
+	Name      string   `json:"name"`
+	Authors   []string `json:"authors,omitempty"`   // pubkeys, or "following" for everyone we follow
+	Lists     []string `json:"lists,omitempty"`     // people lists whose members are authors too
+	Kinds     []int    `json:"kinds,omitempty"`     // default: text notes
+	Hashtags  []string `json:"hashtags,omitempty"`  // any of these
+	Mentions  bool     `json:"mentions,omitempty"`  // only events that tag us
+	Interests bool     `json:"interests,omitempty"` // also notes with the hashtags we follow
+	Relays    []string `json:"relays,omitempty"`    // ask only these relays
+	Replies   string   `json:"replies,omitempty"`   // "include" (default), "exclude" or "only"
+	Include   []string `json:"include,omitempty"`   // keep only events containing one of these
+	Exclude   []string `json:"exclude,omitempty"`   // drop events containing any of these
+}
+
+const followingAuthors = "following"
+
+// defaultFeeds are the feeds of a config without a feeds section.
+func defaultFeeds() []Feed {
+	return []Feed{
+		{Name: "Home", Authors: []string{followingAuthors}, Interests: true, Replies: "exclude"},
+		{Name: "Notes + Comments", Authors: []string{followingAuthors}, Interests: true},
+		{Name: "Aether", Replies: "exclude"},
+		{Name: "Inbox", Kinds: []int{nostr.KindEncryptedDirectMessage}, Mentions: true},
+	}
+}
+
+// findFeed returns the configured feed called name, ignoring case.
+func findFeed(name string) (Feed, bool) {
+	for _, f := range config.Feeds {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Feed{}, false
+}
+
+// directMessages tells whether f is a feed of encrypted direct messages,
+// answered with messages instead of replies.
+func (f Feed) directMessages() bool {
+	return len(f.Kinds) == 1 && f.Kinds[0] == nostr.KindEncryptedDirectMessage
+}
+
+// hashtag is the hashtag f follows when it shows just that, as opened
+// from a note or the Hashtags screen.
+func (f Feed) hashtag() string {
+	if len(f.Hashtags) != 1 || len(f.Authors) > 0 || len(f.Lists) > 0 {
+		return ""
+	}
+	return normalizeHashtag(f.Hashtags[0])
+}
+
+// authors resolves Authors and Lists to pubkeys. It returns nil when the
+// feed isn't limited to some authors.
+func (f Feed) authors() ([]string, error) {
+	if len(f.Authors) == 0 && len(f.Lists) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, author := range f.Authors {
+		if author == followingAuthors {
+			for key := range config.Following {
+				add(key)
+			}
+			continue
+		}
+		add(nip19.TranslatePublicKey(author))
+	}
+	lists := loadPeopleLists()
+	for _, name := range f.Lists {
+		for _, key := range listMembers(lists[name]) {
+			add(key)
+		}
+	}
+	if len(keys) == 0 {
+		if len(f.Lists) > 0 {
+			return nil, errors.New("nobody on " + strings.Join(f.Lists, ", "))
+		}
+		return nil, errors.New("follow someone first")
+	}
+	return keys, nil
+}
+
+// filter returns the relay filter of f and the authors it is limited to.
+func (f Feed) filter(limit int) (nostr.Filter, []string, error) {
+	filter := nostr.Filter{Kinds: f.Kinds, Limit: limit}
+	if len(filter.Kinds) == 0 {
+		filter.Kinds = []int{nostr.KindTextNote}
+	}
+	keys, err := f.authors()
+	if err != nil {
+		return filter, nil, err
+	}
+	filter.Authors = keys
+	tags := nostr.TagMap{}
+	for _, tag := range f.Hashtags {
+		tags["t"] = append(tags["t"], normalizeHashtag(tag))
+	}
+	if f.Mentions {
+		if config.PrivateKey == "" {
+			return filter, nil, errors.New("set key first")
+		}
+		tags["p"] = []string{getPubKey(config.PrivateKey)}
+	}
+	if len(tags) > 0 {
+		filter.Tags = tags
+	}
+	return filter, keys, nil
+}
+
+// interestFilter turns filter into one for the notes with the hashtags we
+// follow. It returns nil unless f includes those.
+func (f Feed) interestFilter(filter nostr.Filter) *nostr.Filter {
+	if !f.Interests {
+		return nil
+	}
+	tags := followedHashtags()
+	if len(tags) == 0 {
+		return nil
+	}
+	filter.Authors = nil
+	filter.Tags = nostr.TagMap{"t": tags}
+	return &filter
+}
+
+// interesting tells whether ev has one of the hashtags we follow and f
+// includes those.
+func (f Feed) interesting(ev nostr.Event) bool {
+	if !f.Interests {
+		return false
+	}
+	followed := make(map[string]bool)
+	for _, tag := range followedHashtags() {
+		followed[tag] = true
+	}
+	// the list is normalized, the tags of others' notes may not be
+	for _, tag := range ev.Tags.GetAll([]string{"t", ""}) {
+		if followed[normalizeHashtag(tag.Value())] {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe asks the feed's relays, or the pool and the authors' own write
+// relays, for events matching filter. The pool must be initialized.
+func (f Feed) subscribe(filter nostr.Filter, keys []string) *authorSub {
+	filters := nostr.Filters{filter}
+	interest := f.interestFilter(filter)
+	if interest != nil {
+		filters = append(filters, *interest)
+	}
+	switch {
+	case len(f.Relays) > 0:
+		return subscribeRelays(f.Relays, filters)
+	case len(keys) > 0:
+		sub := subscribeAuthors(filter, keys)
+		if interest != nil {
+			sub.subscribePool(nostr.Filters{*interest})
+		}
+		return sub
+	}
+	return subscribePool(filters)
+}
+
+// timeout is how long to wait for more events from sub.
+func (f Feed) timeout(sub *authorSub) time.Duration {
+	if sub.extra > 0 {
+		return extraRelayTimeout
+	}
+	return 2 * time.Second
+}
+
+// accepts applies the reply policy and keyword filters relays can't.
+func (f Feed) accepts(ev nostr.Event) bool {
+	if f.Replies == "exclude" || f.Replies == "only" {
+		isReply := ev.Tags.GetFirst([]string{"e", ""}) != nil
+		if isReply != (f.Replies == "only") {
+			return false
+		}
+	}
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return true
+	}
+	content := strings.ToLower(ev.Content)
+	for _, word := range f.Exclude {
+		if strings.Contains(content, strings.ToLower(word)) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, word := range f.Include {
+		if strings.Contains(content, strings.ToLower(word)) {
+			return true
+		}
+	}
+	return false
+}
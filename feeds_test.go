@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestFeedInteresting(t *testing.T) {
+	defer func(dir string) { config.DataDir = dir }(config.DataDir)
+	config.DataDir = t.TempDir()
+	interestSpec.save(nip51List{Items: []listItem{{Type: "t", Value: "nostr"}}})
+
+	tests := []struct {
+		feed Feed
+		tags nostr.Tags
+		want bool
+	}{
+		{Feed{Interests: true}, nostr.Tags{{"t", "nostr"}}, true},
+		{Feed{Interests: true}, nostr.Tags{{"t", "Nostr"}}, true},
+		{Feed{Interests: true}, nostr.Tags{{"t", "#nostr "}}, true},
+		{Feed{Interests: true}, nostr.Tags{{"t", "nostrich"}}, false},
+		{Feed{Interests: true}, nostr.Tags{{"p", "nostr"}}, false},
+		{Feed{}, nostr.Tags{{"t", "nostr"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.feed.interesting(nostr.Event{Tags: tt.tags}); got != tt.want {
+			t.Errorf("interesting(%v) with interests %v = %v, want %v", tt.tags, tt.feed.Interests, got, tt.want)
+		}
+	}
+}
+
+func TestFeedFilterHashtags(t *testing.T) {
+	f := Feed{Hashtags: []string{"#Nostr", " #Go ", "BitCoin"}}
+	filter, _, err := f.filter(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"nostr", "go", "bitcoin"}
+	if got := filter.Tags["t"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("t filter %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const KindInterestList = 10015 // NIP-51
+
+// interestSpec is the list of hashtags we follow. Like the mute list it is
+// cached in the datadir only, not copied into config.json, where it would
+// go stale whenever another client changes the list.
+var interestSpec = listSpec{
+	Kind:  KindInterestList,
+	File:  "interests.json",
+	Types: []string{"t"},
+	Normalize: func(item listItem) listItem {
+		item.Value = normalizeHashtag(item.Value)
+		return item
+	},
+}
+
+func normalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+func validHashtag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, " \t\n#,") {
+		return errors.New("invalid hashtag")
+	}
+	return nil
+}
+
+// followedHashtags returns the hashtags on our cached interest list.
+func followedHashtags() []string {
+	var tags []string
+	for _, item := range interestSpec.load().Items {
+		if item.Type == "t" {
+			tags = append(tags, item.Value)
+		}
+	}
+	return tags
+}
+
+// hashtagFeed shows the notes tagged with tag.
+func hashtagFeed(tag string) Feed {
+	return Feed{Name: "#" + tag, Hashtags: []string{tag}}
+}
+
+// noteHashtags returns the hashtags of ev: its t tags, then those only
+// written in the content.
+func noteHashtags(ev nostr.Event) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, tag := range ev.Tags.GetAll([]string{"t", ""}) {
+		if t := normalizeHashtag(tag.Value()); t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range extractHashtags(ev.Content) {
+		if !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// followHashtag adds tag to our interest list or removes it.
+// The pool must be initialized.
+func followHashtag(tag string, follow, private bool) (*nostr.Event, chan deliveryStatus, error) {
+	tag = normalizeHashtag(tag)
+	if err := validHashtag(tag); err != nil {
+		return nil, nil, err
+	}
+	_, event, statuses, err := interestSpec.update([]listItem{{Type: "t", Value: tag, Private: private}}, follow)
+	return event, statuses, err
+}
+
+// fetchHashtag returns up to limit notes tagged with tag, newest first.
+// The pool must be initialized.
+func fetchHashtag(tag string, limit int) []nostr.Event {
+	f := hashtagFeed(tag)
+	filter, keys, _ := f.filter(limit)
+	sub := f.subscribe(filter, keys)
+	defer sub.Close()
+	mutes := currentMuteFilter()
+	var events []nostr.Event
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), f.timeout(sub)) {
+		if !mutes.muted(ev) {
+			events = append(events, ev)
+		}
+		if len(events) >= limit {
+			break
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	return events
+}
+
+func showHashtag(opts docopt.Opts) {
+	tag := normalizeHashtag(opts["<hashtag>"].(string))
+	if err := validHashtag(tag); err != nil {
+		log.Println(err)
+		return
+	}
+	limit, _ := opts.Int("--limit")
+	if limit <= 0 {
+		limit = feedLimit
+	}
+	verbose, _ := opts.Bool("--verbose")
+	jsonformat, _ := opts.Bool("--json")
+	initNostr()
+
+	events := fetchHashtag(tag, limit)
+	if len(events) == 0 {
+		fmt.Printf("No notes tagged #%s.\n", tag)
+		return
+	}
+	nameMap := make(map[string]string)
+	for _, follow := range config.Following {
+		if follow.Name != "" {
+			nameMap[follow.Key] = follow.Name
+		}
+	}
+	for _, ev := range events {
+		nick := nameMap[ev.PubKey]
+		printEvent(ev, &nick, verbose, jsonformat)
+	}
+}
+
+func hashtags(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Println("No private key set.")
+		return
+	}
+	initNostr()
+
+	if opts["follow"].(bool) || opts["unfollow"].(bool) {
+		tag := normalizeHashtag(opts["<hashtag>"].(string))
+		private, _ := opts.Bool("--private")
+		follow := opts["follow"].(bool)
+		event, statuses, err := followHashtag(tag, follow, private)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if follow {
+			fmt.Printf("Following #%s.\n", tag)
+		} else {
+			fmt.Printf("Unfollowed #%s.\n", tag)
+		}
+		printPublishStatus(event, statuses)
+		return
+	}
+
+	list, err := interestSpec.sync()
+	if err != nil {
+		log.Println(err)
+	}
+	if len(list.Items) == 0 {
+		fmt.Println("No followed hashtags.")
+		return
+	}
+	for _, item := range list.Items {
+		if item.Private {
+			fmt.Println("#"+item.Value, "(private)")
+		} else {
+			fmt.Println("#" + item.Value)
+		}
+	}
+}
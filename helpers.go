@@ -6,8 +6,13 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sync"
 )
 
+// maxContentBytes caps note and message content read from stdin.
+const maxContentBytes = 64 * 1024
+
 func saveConfig(path string) {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
 	if err != nil {
@@ -33,3 +38,49 @@ func readContentStdin(max int) (string, error) {
 	}
 	return string(b), nil
 }
+
+// loadDataFile decodes the JSON file name inside config.DataDir into v.
+// A missing file is not an error and leaves v untouched.
+func loadDataFile(name string, v interface{}) error {
+	b, err := os.ReadFile(filepath.Join(config.DataDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// saveDataFile writes v as indented JSON to name inside config.DataDir.
+func saveDataFile(name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.DataDir, name), b, 0600)
+}
+
+// lockDataFile takes mu and an exclusive lock on name.lock inside
+// config.DataDir, for a load-modify-save of name that other processes
+// sharing the datadir may be doing too. Call the returned function to
+// release both. When the file can't be locked it logs why and only holds mu.
+func lockDataFile(name string, mu *sync.Mutex) (unlock func()) {
+	mu.Lock()
+	f, err := os.OpenFile(filepath.Join(config.DataDir, name+".lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err == nil {
+		err = lockFile(f)
+	}
+	if err != nil {
+		log.Printf("can't lock %s: %s", name, err)
+		if f != nil {
+			f.Close()
+		}
+		return mu.Unlock
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		mu.Unlock()
+	}
+}
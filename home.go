@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"time"
 
@@ -12,7 +13,9 @@ import (
 )
 
 func home(opts docopt.Opts, inboxMode bool) {
-	if len(config.Following) == 0 {
+	listName, _ := opts.String("--list")
+	feedName, _ := opts.String("--feed")
+	if len(config.Following) == 0 && listName == "" && feedName == "" {
 		log.Println("You need to be following someone to run 'home'")
 		return
 	}
@@ -46,9 +49,41 @@ func home(opts docopt.Opts, inboxMode bool) {
 			nameMap[follow.Key] = follow.Name
 		}
 	}
+	if listName != "" {
+		lists, err := syncPeopleLists()
+		if err != nil {
+			log.Println(err)
+		}
+		list, ok := lists[listName]
+		if !ok {
+			log.Printf("No list called %s.\n", listName)
+			return
+		}
+		keys = listMembers(list)
+	}
+	var feed *Feed
+	if feedName != "" {
+		f, ok := findFeed(feedName)
+		if !ok {
+			log.Printf("No feed called %s.\n", feedName)
+			return
+		}
+		feed = &f
+	}
 	pubkey := getPubKey(config.PrivateKey)
 	filters := nostr.Filters{{Limit: limit}}
-	if inboxMode {
+	if feed != nil {
+		filter, feedKeys, err := feed.filter(limit)
+		if err != nil {
+			log.Printf("%s: %s.\n", feed.Name, err)
+			return
+		}
+		if len(intkinds) == 0 {
+			intkinds = filter.Kinds
+		}
+		filters[0] = filter
+		keys = feedKeys
+	} else if inboxMode {
 		// Filter by p tag to me
 		filters[0].Tags = nostr.TagMap{"p": {pubkey}}
 		// Force kinds to encrypted messages
@@ -66,9 +101,34 @@ func home(opts docopt.Opts, inboxMode bool) {
 		filters[0].Until = &untilTime
 	}
 	filters[0].Kinds = intkinds
-	_, all := pool.Sub(filters)
+	// subscribe asks the relays for filter, once per page when paging
+	subscribe := func(filter nostr.Filter) *authorSub {
+		if feed != nil {
+			return feed.subscribe(filter, keys)
+		} else if inboxMode {
+			return subscribePool(nostr.Filters{filter})
+		}
+		// also ask the relays our follows publish to (NIP-65)
+		sub := subscribeAuthors(filter, keys)
+		if listName == "" {
+			// and for the hashtags we follow
+			if interest := (Feed{Interests: true}).interestFilter(filter); interest != nil {
+				sub.subscribePool(nostr.Filters{*interest})
+			}
+		}
+		return sub
+	}
+	// the cached mute list applies right away, changes from other clients
+	// once they are fetched
+	go syncMutes()
 	headerPrinted := false
-	for event := range nostr.Unique(all) {
+	show := func(event nostr.Event) {
+		if currentMuteFilter().muted(event) {
+			return
+		}
+		if feed != nil && !feed.accepts(event) {
+			return
+		}
 		// Do we have a nick for the author of this message?
 		nick, ok := nameMap[event.PubKey]
 		if !ok {
@@ -83,7 +143,7 @@ func home(opts docopt.Opts, inboxMode bool) {
 				err := json.Unmarshal([]byte(event.Content), &metadata)
 				if err != nil {
 					log.Println("Failed to parse metadata.")
-					continue
+					return
 				}
 				nick = metadata.Name
 				nameMap[event.PubKey] = nick
@@ -100,10 +160,10 @@ func home(opts docopt.Opts, inboxMode bool) {
 				}
 			}
 			if noreplies && hasReferences {
-				continue
+				return
 			}
 			if onlyreplies && !hasReferences {
-				continue
+				return
 			}
 		}
 
@@ -119,4 +179,69 @@ func home(opts docopt.Opts, inboxMode bool) {
 			printEvent(event, &nick, verbose, jsonformat)
 		}
 	}
+
+	page, _ := opts.Int("--page")
+	allPages, _ := opts.Bool("--all")
+	if page > 0 || allPages {
+		if limit <= 0 {
+			limit = feedLimit
+		}
+		// subscribe adds the hashtags we follow with the same Until; pages
+		// end at the oldest event shown from either
+		filter := filters[0]
+		filter.Limit = limit
+		walkPages(subscribe, filter, func(n int, events []nostr.Event) bool {
+			if allPages || n == page {
+				for _, event := range events {
+					show(event)
+				}
+			}
+			return allPages || n < page
+		})
+		return
+	}
+
+	sub := subscribe(filters[0])
+	defer sub.Close()
+	for event := range nostr.Unique(sub.Events) {
+		show(event)
+	}
+}
+
+// walkPages fetches the events matching filter a page of filter.Limit at a
+// time, newest first, each page ending where the one before it did, and
+// hands them to page until it returns false or the history runs out. The
+// next page starts at the oldest event of the page, whichever of the
+// subscriptions behind subscribe it came from; older ones that came along
+// are left for the next page.
+func walkPages(subscribe func(nostr.Filter) *authorSub, filter nostr.Filter, page func(n int, events []nostr.Event) bool) {
+	seen := make(map[string]bool)
+	for n := 1; ; n++ {
+		sub := subscribe(filter)
+		var events []nostr.Event
+		for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), (Feed{}).timeout(sub)) {
+			// until is inclusive: the oldest events of the last page come again
+			if !seen[ev.ID] {
+				events = append(events, ev)
+			}
+		}
+		sub.Close()
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		})
+		if len(events) > filter.Limit {
+			events = events[:filter.Limit]
+		}
+		if len(events) == 0 {
+			return
+		}
+		for _, ev := range events {
+			seen[ev.ID] = true
+		}
+		if !page(n, events) {
+			return
+		}
+		until := events[len(events)-1].CreatedAt
+		filter.Until = &until
+	}
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestWalkPages(t *testing.T) {
+	at := func(id string, unix int64) nostr.Event {
+		return nostr.Event{ID: id, Kind: nostr.KindTextNote, CreatedAt: time.Unix(unix, 0)}
+	}
+	history := []nostr.Event{at("1", 100), at("2", 200), at("3", 300), at("4", 400)}
+
+	tests := []struct {
+		note   string
+		stopAt int // page returning false, 0 for none
+		pages  [][]string
+		untils []int64 // of each request, 0 for none
+	}{
+		// until is inclusive, so 3 comes again on the second page and 1 on
+		// the third, which ends the walk
+		{"whole history", 0, [][]string{{"4", "3"}, {"2", "1"}}, []int64{0, 300, 100}},
+		{"stopped", 1, [][]string{{"4", "3"}}, []int64{0}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.note, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			var untils []int64
+			// like a relay ignoring the limit, sending everything twice
+			subscribe := func(filter nostr.Filter) *authorSub {
+				mu.Lock()
+				if filter.Until == nil {
+					untils = append(untils, 0)
+				} else {
+					untils = append(untils, filter.Until.Unix())
+				}
+				mu.Unlock()
+				s := newAuthorSub()
+				go func() {
+					for i := len(history) - 1; i >= 0; i-- {
+						ev := history[i]
+						if !filter.Matches(&ev) {
+							continue
+						}
+						for n := 0; n < 2; n++ {
+							select {
+							case s.Events <- nostr.EventMessage{Event: ev}:
+							case <-s.done:
+								return
+							}
+						}
+					}
+				}()
+				return s
+			}
+			var pages [][]string
+			walkPages(subscribe, nostr.Filter{Kinds: []int{nostr.KindTextNote}, Limit: 2}, func(n int, events []nostr.Event) bool {
+				if n != len(pages)+1 {
+					t.Errorf("page %d after %d pages", n, len(pages))
+				}
+				var ids []string
+				for _, ev := range events {
+					ids = append(ids, ev.ID)
+				}
+				pages = append(pages, ids)
+				return n != tt.stopAt
+			})
+			if !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("pages %v, want %v", pages, tt.pages)
+			}
+			if !reflect.DeepEqual(untils, tt.untils) {
+				t.Errorf("asked until %v, want %v", untils, tt.untils)
+			}
+		})
+	}
+}
+
+func TestWalkPagesInterests(t *testing.T) {
+	at := func(id string, unix int64) nostr.Event {
+		return nostr.Event{ID: id, Kind: nostr.KindTextNote, CreatedAt: time.Unix(unix, 0)}
+	}
+	// the notes of follows, and the notes with followed hashtags, which run
+	// further back than the first page of the others
+	follows := []nostr.Event{at("f1000", 1000), at("f900", 900), at("f800", 800)}
+	interests := []nostr.Event{at("i950", 950), at("i700", 700), at("i600", 600)}
+
+	// like relays keeping to the limit, one subscription for each
+	subscribe := func(filter nostr.Filter) *authorSub {
+		s := newAuthorSub()
+		for _, source := range [][]nostr.Event{follows, interests} {
+			go func(source []nostr.Event) {
+				n := 0
+				for _, ev := range source {
+					if n == filter.Limit || !filter.Matches(&ev) {
+						continue
+					}
+					n++
+					select {
+					case s.Events <- nostr.EventMessage{Event: ev}:
+					case <-s.done:
+						return
+					}
+				}
+			}(source)
+		}
+		return s
+	}
+	var pages [][]string
+	walkPages(subscribe, nostr.Filter{Kinds: []int{nostr.KindTextNote}, Limit: 3}, func(n int, events []nostr.Event) bool {
+		var ids []string
+		for _, ev := range events {
+			ids = append(ids, ev.ID)
+		}
+		pages = append(pages, ids)
+		return true
+	})
+	// i700 and i600 came with the first page but are older than its end:
+	// they are shown once, on the second
+	want := [][]string{{"f1000", "i950", "f900"}, {"f800", "i700", "i600"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages %v, want %v", pages, want)
+	}
+}
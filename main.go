@@ -15,19 +15,52 @@ const USAGE = `noscl
 
 Usage:
   noscl tui
-  noscl home [--gopher] [--verbose] [--json] [--onlyreplies] [--noreplies] [--kinds=<kinds>...] [--since=<since>] [--until=<until>] [--limit=<limit>]
-  noscl inbox [--gopher] [--verbose] [--json] [--onlyreplies] [--noreplies] [--since=<since>] [--until=<until>] [--limit=<limit>]
+  noscl home [--gopher] [--verbose] [--json] [--onlyreplies] [--noreplies] [--kinds=<kinds>...] [--since=<since>] [--until=<until>] [--limit=<limit>] [--list=<name>] [--feed=<name>] [--page=<n>] [--all]
+  noscl inbox [--gopher] [--verbose] [--json] [--onlyreplies] [--noreplies] [--since=<since>] [--until=<until>] [--limit=<limit>] [--page=<n>] [--all]
+  noscl stream [--kinds=<kinds>...] [--authors=<pubkey>...] [--tags=<tag>...] [--since=<time>] [--limit=<limit>] [--format=<format>] [--template=<template>]
+  noscl req [--relay=<url>...] [--stream] [--timeout=<seconds>] <filter>...
   noscl setprivate <key>
   noscl sign <event-json>
   noscl verify <event-json>
   noscl public
-  noscl publish [--reference=<id>...] [--profile=<id>...] [--file=<file>] [<content>]
-  noscl message [--reference=<id>...] <pubkey> <content>
-  noscl metadata --name=<name> [--about=<about>] [--picture=<picture>] [--nip05=<nip05>] [--banner=<banner>] [--lud16=<lud16>] [--website=<website>]
+  noscl publish [--edit] [--at=<time>] [--reference=<id>...] [--profile=<id>...] [--file=<file>] [<content>]
+  noscl reply [--edit] <id> [<content>]
+  noscl message [--edit] [--reference=<id>...] <pubkey> [<content>]
+  noscl scheduled
+  noscl scheduled cancel <id>
+  noscl daemon [--interval=<seconds>]
+  noscl outbox [--all]
+  noscl outbox retry
+  noscl outbox clear
+  noscl metadata [--name=<name>] [--about=<about>] [--picture=<picture>] [--nip05=<nip05>] [--banner=<banner>] [--lud16=<lud16>] [--website=<website>] [--yes] [--force]
   noscl profile [--verbose] [--json] <pubkey>
   noscl follow <pubkey> [--name=<name>]
+  noscl follow --from=<pubkey> [--yes]
   noscl unfollow <pubkey>
   noscl following
+  noscl following sync [--pull | --push | --merge] [--force]
+  noscl lists [<pubkey>]
+  noscl lists show <name>
+  noscl lists add [--private] <name> <member>...
+  noscl lists remove <name> <member>...
+  noscl lists delete <name>
+  noscl lists import <pubkey> <name> [--as=<name>]
+  noscl mute
+  noscl mute [--private] <pubkey>
+  noscl mute [--private] (--hashtag=<tag> | --word=<word> | --thread=<id>)
+  noscl unmute <pubkey>
+  noscl unmute (--hashtag=<tag> | --word=<word> | --thread=<id>)
+  noscl search [--verbose] [--json] [--author=<pubkey>] [--kind=<kind>] [--since=<time>] [--limit=<limit>] <query>...
+  noscl tag [--verbose] [--json] [--limit=<limit>] <hashtag>
+  noscl tags
+  noscl tags follow [--private] <hashtag>
+  noscl tags unfollow <hashtag>
+  noscl bookmarks list [--verbose] [--json]
+  noscl bookmarks add [--private] <id>
+  noscl bookmarks remove <id>
+  noscl pin <id>
+  noscl unpin <id>
+  noscl pins [--verbose] [--json] [<pubkey>]
   noscl event view [--gopher] [--verbose] [--json] <id>
   noscl event delete <id>
   noscl share-contacts
@@ -37,9 +70,95 @@ Usage:
   noscl relay remove [--all]
   noscl relay remove <url>
   noscl relay recommend <url>
+  noscl relay publish
+  noscl relay import [--replace] <pubkey>
+  noscl nip05
+  noscl nip05 add <name> <pubkey> [--relay=<url>...]
+  noscl nip05 remove <name>
+  noscl nip05 serve [--listen=<addr>]
 
-Specify <content> as '-' to make the publish or message command read it
-from stdin.
+Specify <content> as '-' to make the publish, reply or message command read
+it from stdin. --edit opens the content in $EDITOR before publishing.
+--at=<time> (e.g. 2026-11-01T09:00) signs the note now and queues it; the
+daemon command or a running TUI publishes it once it is due.
+
+Every published event goes through the outbox in the datadir first. Relays
+that don't acknowledge it are retried with backoff by the daemon, a running
+TUI or 'noscl outbox retry'.
+
+'relay publish' announces the configured relays as a NIP-65 relay list
+(kind 10002); 'relay import' adopts someone else's list, merging it into the
+configured relays unless --replace is given. home also reads from the write
+relays in the relay lists of the people you follow (up to 8 extra relays).
+
+'following sync' compares the local follows with your newest contact list
+(kind 3) on the relays. --pull replaces the local follows, --push publishes
+them and --merge does both with the union of the two lists. When no relay
+replies, --push and --merge only publish with --force.
+
+lists manages named people lists (NIP-51 follow sets, kind 30000) such as
+"team" or "news". Members don't need to be followed. 'home --list=<name>'
+shows the notes of one list. 'lists <pubkey>' shows the lists someone
+published and 'lists import' copies one of them into yours, named --as.
+
+'home --feed=<name>' shows one of the feeds configured in the "feeds" section
+of config.json, the same feeds the TUI menu lists (see README).
+
+home and inbox keep waiting for new events. With --page=<n> they instead show
+the n-th page of --limit events (default 25) back in history and exit; each
+page starts where the one before it ended. --all shows every page in turn.
+
+stream is a tail -f for events: it keeps a subscription open on every read
+relay and prints each matching event once as it arrives, until interrupted.
+Without --since or --limit it starts with the events from now on. --kinds
+and --tags take comma-separated values, tags as name=values (e.g. t=nostr).
+--format is text (default), gopher, json (one event per line) or template,
+a Go template given with --template such as '{{.CreatedAt}} {{.Content}}'.
+Dropped connections are reopened, asking only for newer events.
+
+req sends raw NIP-01 filters, JSON objects or arrays of them, to the read
+relays or those given with --relay, e.g. '{"kinds":[1],"#t":["nostr"]}'.
+A <filter> of - reads them from stdin. Fields are passed on as written, so
+tag filters like "#e" or "#d" and id prefixes work as far as the relay does.
+Events are printed once each as NDJSON; how every relay answered (EOSE,
+CLOSED, NOTICE, errors) goes to stderr. It ends after EOSE, or a silence of
+--timeout seconds (default 10), unless --stream keeps the subscriptions open.
+
+search looks for notes (and NIP-23 articles) containing all words of <query>,
+on the read relays that support NIP-50 according to their NIP-11 document
+and in the notes seen before, which are kept in events.json in the datadir.
+--since takes a date like --at or a unix timestamp.
+
+tag shows recent notes with a hashtag. 'tags follow' adds a hashtag to your
+interest list (NIP-51 kind 10015); home then also shows notes with it. The
+list is kept in interests.json in the datadir rather than in config.json, as
+other clients edit it too.
+
+'follow --from' goes through the contact list of someone else and asks which
+of their follows to follow too; --yes follows all of them.
+
+metadata changes only the given fields of your current profile (kind 0) and
+keeps everything else, including fields other clients added. An empty value
+removes a field. The changes are shown and confirmed before publishing unless
+--yes is given. A current profile that can't be read is only replaced with
+--force.
+
+follow also takes a NIP-05 identifier (alice@example.com) instead of a
+pubkey; its relays become the relay hints and the name the petname. Verified
+identifiers are marked with a check mark and cached in nip05cache.json.
+
+'nip05 serve' answers /.well-known/nostr.json requests (default --listen=:8080)
+for the names kept in nip05.json in the datadir, which 'nip05 add' and
+'nip05 remove' manage. Put it behind the HTTPS server of your domain.
+
+mute hides a pubkey, hashtag, word or thread (and its replies) in home, inbox
+and the TUI. A --word written as /.../ is a regular expression. The mute list
+is published as a NIP-51 list (kind 10000); --private items are encrypted to
+yourself (NIP-44). mute without arguments lists it.
+
+bookmarks (NIP-51 kind 10003) and pins (kind 10001) are lists on the relays
+too, so they follow you across devices. Only your own notes can be pinned;
+pins shows them for you or for <pubkey>.
 `
 
 func main() {
@@ -86,6 +205,10 @@ func main() {
 		home(opts, false)
 	case opts["inbox"].(bool):
 		home(opts, true)
+	case opts["stream"].(bool):
+		stream(opts)
+	case opts["req"].(bool):
+		req(opts)
 	case opts["setprivate"].(bool):
 		// TODO make this read STDIN and encrypt the key locally
 		setPrivateKey(opts)
@@ -96,10 +219,18 @@ func main() {
 		verifyEventJSON(opts)
 	case opts["public"].(bool):
 		showPublicKey(opts)
-	case opts["publish"].(bool):
+	case opts["publish"].(bool) && !opts["relay"].(bool):
 		publish(opts)
+	case opts["reply"].(bool):
+		reply(opts)
 	case opts["message"].(bool):
 		message(opts)
+	case opts["scheduled"].(bool):
+		listScheduled(opts)
+	case opts["daemon"].(bool):
+		daemon(opts)
+	case opts["outbox"].(bool):
+		outbox(opts)
 	case opts["share-contacts"].(bool):
 		shareContacts(opts)
 	case opts["key-gen"].(bool):
@@ -108,13 +239,43 @@ func main() {
 		setMetadata(opts)
 	case opts["profile"].(bool):
 		showProfile(opts)
+	case opts["search"].(bool):
+		searchNotes(opts)
+	case opts["tag"].(bool):
+		showHashtag(opts)
+	case opts["tags"].(bool):
+		// before follow and unfollow, which are also its subcommands
+		hashtags(opts)
 	case opts["follow"].(bool):
-		follow(opts)
+		if from, _ := opts.String("--from"); from != "" {
+			followFrom(opts)
+		} else {
+			follow(opts)
+		}
 		saveConfig(path)
 	case opts["unfollow"].(bool):
 		unfollow(opts)
 		saveConfig(path)
+	case opts["mute"].(bool):
+		mute(opts, true)
+	case opts["unmute"].(bool):
+		mute(opts, false)
+	case opts["bookmarks"].(bool):
+		bookmarks(opts)
+	case opts["pin"].(bool):
+		pin(opts, true)
+	case opts["unpin"].(bool):
+		pin(opts, false)
+	case opts["pins"].(bool):
+		showPins(opts)
+	case opts["lists"].(bool):
+		peopleLists(opts)
 	case opts["following"].(bool):
+		if opts["sync"].(bool) {
+			syncFollowing(opts)
+			saveConfig(path)
+			break
+		}
 		following(opts)
 	case opts["event"].(bool):
 		switch {
@@ -123,6 +284,8 @@ func main() {
 		case opts["delete"].(bool):
 			deleteEvent(opts)
 		}
+	case opts["nip05"].(bool):
+		nip05(opts)
 	case opts["relay"].(bool):
 		switch {
 		case opts["add"].(bool):
@@ -133,6 +296,11 @@ func main() {
 			saveConfig(path)
 		case opts["recommend"].(bool):
 			recommendRelay(opts)
+		case opts["publish"].(bool):
+			publishRelayList(opts)
+		case opts["import"].(bool):
+			importRelayList(opts)
+			saveConfig(path)
 		default:
 			listRelays(opts)
 		}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	hashtagsFile    = "hashtags.json"
+	maxSuggestions  = 6
+	maxUsedHashtags = 200
+)
+
+var (
+	hashtagRegex = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_]+)`)
+	mentionRegex = regexp.MustCompile(`nostr:(npub1[02-9ac-hj-np-z]+)`)
+)
+
+// suggestion is one autocompletion candidate shown in the composer.
+// insert is what replaces the typed token; pubkey is set for mentions.
+type suggestion struct {
+	label  string
+	insert string
+	pubkey string
+}
+
+// extractHashtags returns the lowercased, de-duplicated hashtags in content.
+func extractHashtags(content string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range hashtagRegex.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(m[1])
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// extractMentions returns the hex pubkeys of all nostr:npub references in content.
+func extractMentions(content string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range mentionRegex.FindAllStringSubmatch(content, -1) {
+		key := nip19.TranslatePublicKey(m[1])
+		if len(key) != 64 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, key)
+	}
+	return out
+}
+
+// contentTags appends p tags for mentioned pubkeys and t tags for hashtags
+// found in content to tags, skipping any that are already present.
+func contentTags(tags nostr.Tags, content string) nostr.Tags {
+	for _, key := range extractMentions(content) {
+		if !tags.ContainsAny("p", []string{key}) {
+			tags = append(tags, nostr.Tag{"p", key})
+		}
+	}
+	for _, tag := range extractHashtags(content) {
+		if !tags.ContainsAny("t", []string{tag}) {
+			tags = append(tags, nostr.Tag{"t", tag})
+		}
+	}
+	return tags
+}
+
+// loadUsedHashtags returns the hashtags we have published before, most recent first.
+func loadUsedHashtags() []string {
+	var tags []string
+	if err := loadDataFile(hashtagsFile, &tags); err != nil {
+		log.Printf("can't read %s: %s", hashtagsFile, err)
+	}
+	return tags
+}
+
+// rememberHashtags moves the hashtags in content to the front of the used list.
+func rememberHashtags(content string) {
+	used := extractHashtags(content)
+	if len(used) == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, tag := range used {
+		seen[tag] = true
+	}
+	for _, tag := range loadUsedHashtags() {
+		if !seen[tag] {
+			seen[tag] = true
+			used = append(used, tag)
+		}
+	}
+	if len(used) > maxUsedHashtags {
+		used = used[:maxUsedHashtags]
+	}
+	if err := saveDataFile(hashtagsFile, used); err != nil {
+		log.Printf("can't write %s: %s", hashtagsFile, err)
+	}
+}
+
+// fuzzyScore reports whether every rune of query appears in candidate in
+// order (case-insensitive). Lower scores are better matches; prefix and
+// contiguous matches score lowest.
+func fuzzyScore(query, candidate string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	c := []rune(strings.ToLower(candidate))
+	if len(q) == 0 {
+		return len(c), true
+	}
+	score, qi, last := 0, 0, -1
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if c[ci] != q[qi] {
+			continue
+		}
+		if last >= 0 {
+			score += ci - last - 1
+		} else {
+			score += ci * 2
+		}
+		last = ci
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score*4 + len(c) - len(q), true
+}
+
+// completionToken returns the @mention or #hashtag being typed right before
+// pos in value, including its trigger character, or "" when there is none.
+func completionToken(value string, pos int) string {
+	runes := []rune(value)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+	start := pos
+	for start > 0 {
+		r := runes[start-1]
+		if r == ' ' || r == '\n' || r == '\t' {
+			break
+		}
+		start--
+	}
+	token := string(runes[start:pos])
+	if len(token) == 0 || (token[0] != '@' && token[0] != '#') {
+		return ""
+	}
+	if strings.ContainsAny(token[1:], "@#") {
+		return ""
+	}
+	return token
+}
+
+// mentionSuggestions fuzzy-matches query against petnames from config.Following
+// and names from the profile cache in nameMap.
+func mentionSuggestions(query string, nameMap map[string]string) []suggestion {
+	names := make(map[string]string)
+	for k, v := range nameMap {
+		if v != "" {
+			names[k] = v
+		}
+	}
+	for _, f := range config.Following {
+		if f.Name != "" {
+			names[f.Key] = f.Name
+		}
+	}
+	type scored struct {
+		s     suggestion
+		score int
+	}
+	var matches []scored
+	for key, name := range names {
+		score, ok := fuzzyScore(query, name)
+		if !ok {
+			continue
+		}
+		npub, err := nip19.EncodePublicKey(key, "")
+		if err != nil {
+			continue
+		}
+		matches = append(matches, scored{
+			s:     suggestion{label: "@" + name + " (" + shorten(key) + ")", insert: "nostr:" + npub, pubkey: key},
+			score: score,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].s.label < matches[j].s.label
+	})
+	out := make([]suggestion, 0, maxSuggestions)
+	for _, m := range matches {
+		if len(out) >= maxSuggestions {
+			break
+		}
+		out = append(out, m.s)
+	}
+	return out
+}
+
+// hashtagSuggestions fuzzy-matches query against previously used hashtags and
+// hashtags seen on the given events. Better matches come first, then the
+// hashtags used more: by us, counting once, and on each of the events.
+func hashtagSuggestions(query string, events []nostr.Event) []suggestion {
+	candidates := loadUsedHashtags()
+	uses := make(map[string]int)
+	for _, tag := range candidates {
+		uses[tag] = 1
+	}
+	for _, ev := range events {
+		counted := make(map[string]bool)
+		for _, tag := range ev.Tags {
+			if len(tag) < 2 || tag[0] != "t" {
+				continue
+			}
+			name := strings.ToLower(tag[1])
+			if name == "" || counted[name] {
+				continue
+			}
+			counted[name] = true
+			if _, ok := uses[name]; !ok {
+				candidates = append(candidates, name)
+			}
+			uses[name]++
+		}
+	}
+	type scored struct {
+		tag   string
+		score int
+	}
+	var matches []scored
+	for _, tag := range candidates {
+		if tag == strings.ToLower(query) {
+			continue
+		}
+		if score, ok := fuzzyScore(query, tag); ok {
+			matches = append(matches, scored{tag: tag, score: score})
+		}
+	}
+	// stable, so that among equals the ones we used recently come first
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return uses[matches[i].tag] > uses[matches[j].tag]
+	})
+	out := make([]suggestion, 0, maxSuggestions)
+	for _, m := range matches {
+		if len(out) >= maxSuggestions {
+			break
+		}
+		out = append(out, suggestion{label: "#" + m.tag, insert: "#" + m.tag})
+	}
+	return out
+}
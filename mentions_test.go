@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		score            int
+		ok               bool
+	}{
+		{"", "abc", 3, true},
+		{"go", "golf", 2, true},
+		{"GO", "golf", 2, true},
+		{"go", "ago", 9, true},
+		{"gf", "golf", 10, true},
+		{"ü", "Über", 3, true},
+		{"og", "golf", 0, false},
+		{"golfer", "golf", 0, false},
+		{"x", "", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyScore(tt.query, tt.candidate)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %v, want %d, %v", tt.query, tt.candidate, score, ok, tt.score, tt.ok)
+		}
+	}
+	// a prefix beats the same letters further in or spread out
+	prefix, _ := fuzzyScore("nos", "nostr")
+	inner, _ := fuzzyScore("nos", "xnostr")
+	spread, _ := fuzzyScore("nos", "nxoxsx")
+	if prefix >= inner || prefix >= spread {
+		t.Errorf("scores prefix %d, inner %d, spread %d: want the prefix lowest", prefix, inner, spread)
+	}
+}
+
+func TestCompletionToken(t *testing.T) {
+	tests := []struct {
+		value string
+		pos   int
+		want  string
+	}{
+		{"", 0, ""},
+		{"@al", 3, "@al"},
+		{"hi @al", 6, "@al"},
+		{"hi @al", 5, "@a"},
+		{"hi @al there", 12, ""},
+		{"#", 1, "#"},
+		{"about #go\n#nos", 14, "#nos"},
+		{"tabs\t#x", 7, "#x"},
+		{"mail a@b", 8, ""},
+		{"@a#b", 4, ""},
+		{"#über", 5, "#über"},
+		{"hi @al", 99, "@al"},
+	}
+	for _, tt := range tests {
+		if got := completionToken(tt.value, tt.pos); got != tt.want {
+			t.Errorf("completionToken(%q, %d) = %q, want %q", tt.value, tt.pos, got, tt.want)
+		}
+	}
+}
+
+func TestHashtagSuggestions(t *testing.T) {
+	defer func(dir string) { config.DataDir = dir }(config.DataDir)
+	config.DataDir = t.TempDir()
+	rememberHashtags("#nostrich #golang")
+	rememberHashtags("#golf #nostr")
+
+	tagged := func(tags ...string) nostr.Event {
+		var ev nostr.Event
+		for _, tag := range tags {
+			ev.Tags = append(ev.Tags, nostr.Tag{"t", tag})
+		}
+		return ev
+	}
+	events := []nostr.Event{
+		tagged("Go", "go"),
+		tagged("go", "gopher"),
+		tagged("gopher"),
+		tagged("gopher", "nostr"),
+		tagged("bitcoin"),
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"#go", "#golf", "#nostr", "#gopher", "#golang", "#bitcoin"}},
+		// equal scores: used more first, then used by us more recently
+		{"go", []string{"#golf", "#gopher", "#golang"}},
+		{"g", []string{"#go", "#golf", "#gopher", "#golang"}},
+		{"nos", []string{"#nostr", "#nostrich"}},
+		{"gpr", []string{"#gopher"}},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range hashtagSuggestions(tt.query, events) {
+			got = append(got, s.insert)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("hashtagSuggestions(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
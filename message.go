@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/hex"
 	"log"
 	"time"
 
@@ -15,8 +16,6 @@ func message(opts docopt.Opts) {
 		return
 	}
 
-	initNostr()
-
 	var tags nostr.Tags
 	receiverKey := opts["<pubkey>"].(string)
 	tags = append(tags, nostr.Tag{"p", receiverKey})
@@ -30,15 +29,16 @@ func message(opts docopt.Opts) {
 	}
 
 	// parse and encrypt content
-	message := opts["<content>"].(string)
-	if message == "-" {
-		message, err = readContentStdin(4096)
-		if err != nil {
-			log.Printf("Failed reading content from stdin: %s", err)
-			return
-		}
+	message, err := readContentOpt(opts, "Write your message to "+shorten(receiverKey)+" above the line.")
+	if err != nil {
+		log.Printf("Failed reading content: %s", err)
+		return
+	}
+	if message == "" {
+		log.Printf("Message must not be empty")
+		return
 	}
-	sharedSecret, err := nip04.ComputeSharedSecret(config.PrivateKey, receiverKey)
+	sharedSecret, err := nip04.ComputeSharedSecret(hex.EncodeToString([]byte(config.PrivateKey)), receiverKey)
 	if err != nil {
 		log.Printf("Error computing shared key: %s. \n", err.Error())
 		return
@@ -50,7 +50,7 @@ func message(opts docopt.Opts) {
 		return
 	}
 
-	event, statuses, err := pool.PublishEvent(&nostr.Event{
+	event, statuses, err := publishEvent(&nostr.Event{
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindEncryptedDirectMessage,
 		Tags:      tags,
@@ -61,7 +61,5 @@ func message(opts docopt.Opts) {
 		return
 	}
 
-    log.Printf("%+v\n", event)
-    log.Printf("%+v\n", statuses)
-	// printPublishStatus(event, statuses)
+	printPublishStatus(event, statuses)
 }
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/docopt/docopt-go"
@@ -20,34 +26,166 @@ type Metadata struct {
 
 }
 
-func setMetadata(opts docopt.Opts) {
-	initNostr()
+// metadataFields are the kind-0 fields noscl edits, in display order.
+var metadataFields = []string{"name", "about", "picture", "nip05", "banner", "lud16", "website"}
 
-	name, _ := opts.String("--name")
-	about, _ := opts.String("--about")
-	picture, _ := opts.String("--picture")
-    nip05, _ := opts.String("--nip05")
-    banner, _ := opts.String("--banner")
-    lud16, _ := opts.String("--lud16")
-    website, _ := opts.String("--website")
-
-	jmetadata, _ := json.Marshal(Metadata{
-		Name:           name,
-		About:          about,
-		Picture:        picture,
-        NIP05:          nip05,
-        Banner:         banner,
-        LUD16:          lud16,
-        Website:        website,
-	})
+// errProfileUnreadable means the newest kind-0 isn't a JSON object, so an
+// edit can't keep its fields.
+var errProfileUnreadable = errors.New("current profile unreadable")
+
+// fetchMetadata returns the newest kind-0 of pubkey decoded into a map, so
+// fields added by other clients survive an edit. The event is nil when the
+// relays don't have one; when no relay replied it fails with errNoReply,
+// and with errProfileUnreadable, returning the event, when its content
+// can't be decoded. The pool must be initialized.
+func fetchMetadata(pubkey string) (map[string]interface{}, *nostr.Event, error) {
+	fields := make(map[string]interface{})
+	newest, err := queryNewest([]string{pubkey}, nostr.KindSetMetadata)
+	if err != nil {
+		return fields, nil, err
+	}
+	ev, ok := newest[pubkey]
+	if !ok {
+		return fields, nil, nil
+	}
+	if err := json.Unmarshal([]byte(ev.Content), &fields); err != nil {
+		return make(map[string]interface{}), &ev, fmt.Errorf("%w: %s", errProfileUnreadable, err)
+	}
+	return fields, &ev, nil
+}
+
+// metadataValue returns a field as text; fields that aren't strings are
+// shown as JSON.
+func metadataValue(fields map[string]interface{}, key string) string {
+	switch v := fields[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		j, _ := json.Marshal(v)
+		return string(j)
+	}
+}
+
+// mergeMetadata applies changes to a copy of fields. An empty value removes
+// the field.
+func mergeMetadata(fields map[string]interface{}, changes map[string]string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(fields)+len(changes))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// metadataDiff lists the fields that differ between before and after as
+// "- key: old" / "+ key: new" lines, known fields first.
+func metadataDiff(before, after map[string]interface{}) []string {
+	keys := append([]string{}, metadataFields...)
+	known := make(map[string]bool)
+	for _, key := range metadataFields {
+		known[key] = true
+	}
+	var others []string
+	for _, m := range []map[string]interface{}{before, after} {
+		for key := range m {
+			if !known[key] {
+				known[key] = true
+				others = append(others, key)
+			}
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	var lines []string
+	for _, key := range keys {
+		a, b := metadataValue(before, key), metadataValue(after, key)
+		if a == b {
+			continue
+		}
+		if _, ok := before[key]; ok {
+			lines = append(lines, "- "+key+": "+a)
+		}
+		if _, ok := after[key]; ok {
+			lines = append(lines, "+ "+key+": "+b)
+		}
+	}
+	return lines
+}
 
-	event, statuses, err := pool.PublishEvent(&nostr.Event{
+func publishMetadata(fields map[string]interface{}) (*nostr.Event, chan deliveryStatus, error) {
+	jmetadata, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publishEvent(&nostr.Event{
 		PubKey:    getPubKey(config.PrivateKey),
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindSetMetadata,
 		Tags:      make(nostr.Tags, 0),
 		Content:   string(jmetadata),
 	})
+}
+
+func setMetadata(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Println("No private key set.")
+		return
+	}
+	changes := make(map[string]string)
+	for _, field := range metadataFields {
+		if value, err := opts.String("--" + field); err == nil {
+			changes[field] = strings.TrimSpace(value)
+		}
+	}
+	if len(changes) == 0 {
+		log.Println("Give at least one field to change.")
+		return
+	}
+
+	initNostr()
+
+	current, ev, err := fetchMetadata(getPubKey(config.PrivateKey))
+	force, _ := opts.Bool("--force")
+	switch {
+	case errors.Is(err, errProfileUnreadable) && force:
+		fmt.Printf("Replacing your profile: %s.\n", err)
+	case errors.Is(err, errProfileUnreadable):
+		log.Printf("Can't edit your profile: %s. --force replaces it with only the given fields.\n", err)
+		return
+	case err != nil:
+		// publishing now would drop the fields of a profile we didn't get
+		log.Printf("Can't fetch your profile: %s.\n", err)
+		return
+	case ev == nil:
+		fmt.Println("No profile found on the relays, creating a new one.")
+	}
+	updated := mergeMetadata(current, changes)
+	diff := metadataDiff(current, updated)
+	if len(diff) == 0 {
+		fmt.Println("Nothing to change.")
+		return
+	}
+	for _, line := range diff {
+		fmt.Println(line)
+	}
+	if yes, _ := opts.Bool("--yes"); !yes {
+		fmt.Print("Publish? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return
+		}
+	}
+
+	event, statuses, err := publishMetadata(updated)
 	if err != nil {
 		log.Printf("Error publishing: %s.\n", err.Error())
 		return
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	fields := map[string]interface{}{
+		"name":    "alice",
+		"about":   "hi",
+		"bot":     false,
+		"display": "Alice",
+	}
+	tests := []struct {
+		changes map[string]string
+		want    map[string]interface{}
+	}{
+		{nil, fields},
+		{
+			map[string]string{"name": "bob", "website": "https://bob.example"},
+			map[string]interface{}{"name": "bob", "about": "hi", "bot": false, "display": "Alice", "website": "https://bob.example"},
+		},
+		{
+			map[string]string{"about": "", "bot": "", "lud16": ""},
+			map[string]interface{}{"name": "alice", "display": "Alice"},
+		},
+	}
+	for _, tt := range tests {
+		if got := mergeMetadata(fields, tt.changes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeMetadata(%v) = %v, want %v", tt.changes, got, tt.want)
+		}
+	}
+	if len(fields) != 4 || fields["name"] != "alice" {
+		t.Errorf("fields changed: %v", fields)
+	}
+}
+
+func TestMetadataDiff(t *testing.T) {
+	before := map[string]interface{}{"name": "alice", "about": "hi", "zap": "x", "bot": false}
+	after := map[string]interface{}{"name": "bob", "about": "hi", "website": "https://bob.example", "bot": true, "alpha": "a"}
+	want := []string{
+		"- name: alice",
+		"+ name: bob",
+		"+ website: https://bob.example",
+		"+ alpha: a",
+		"- bot: false",
+		"+ bot: true",
+		"- zap: x",
+	}
+	if got := metadataDiff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("metadataDiff = %q, want %q", got, want)
+	}
+	if got := metadataDiff(before, before); got != nil {
+		t.Errorf("metadataDiff of the same fields = %q", got)
+	}
+}
+
+func TestFetchMetadata(t *testing.T) {
+	key := getPubKey(testPrivateKey)
+	profile := func(content string) nostr.Event {
+		return signedTestEvent(t, nostr.Event{CreatedAt: time.Unix(1700000000, 0), Kind: nostr.KindSetMetadata, Content: content})
+	}
+	tests := []struct {
+		note   string
+		events []nostr.Event
+		fields map[string]interface{}
+		found  bool
+		err    error
+	}{
+		{"readable", []nostr.Event{profile(`{"name":"alice","bot":false}`)},
+			map[string]interface{}{"name": "alice", "bot": false}, true, nil},
+		{"unreadable", []nostr.Event{profile(`{"name":"alice"`)}, map[string]interface{}{}, true, errProfileUnreadable},
+	}
+	for _, tt := range tests {
+		testPool(t, storedRelay(t, tt.events...))
+		fields, ev, err := fetchMetadata(key)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error %v, want %v", tt.note, err, tt.err)
+		}
+		if (ev != nil) != tt.found || !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%s: fields %v, event %v", tt.note, fields, ev)
+		}
+	}
+}
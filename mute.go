@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const KindMuteList = 10000 // NIP-51
+
+// muteSpec is the mute list: pubkeys ("p"), hashtags ("t"), words and
+// threads ("e"). Words written as /.../ are regular expressions.
+var muteSpec = listSpec{
+	Kind:  KindMuteList,
+	File:  "mutes.json",
+	Types: []string{"p", "t", "word", "e"},
+	Normalize: func(item listItem) listItem {
+		switch item.Type {
+		case "t":
+			item.Value = normalizeHashtag(item.Value)
+		case "word":
+			if !strings.HasPrefix(item.Value, "/") {
+				item.Value = strings.ToLower(item.Value)
+			}
+		}
+		return item
+	},
+}
+
+// muteFilter is the mute list prepared for matching events.
+type muteFilter struct {
+	pubkeys  map[string]bool
+	hashtags map[string]bool
+	threads  map[string]bool
+	words    []string
+	regexps  []*regexp.Regexp
+}
+
+var errNothingToMute = errors.New("nothing to mute")
+
+var muteState struct {
+	sync.Mutex
+	filter *muteFilter
+}
+
+// muteLabel describes a mute list entry; muted events are whole threads.
+func muteLabel(item listItem) string {
+	if item.Type == "e" {
+		return "thread " + item.Value
+	}
+	return item.String()
+}
+
+func newMuteFilter(list nip51List) *muteFilter {
+	f := &muteFilter{
+		pubkeys:  make(map[string]bool),
+		hashtags: make(map[string]bool),
+		threads:  make(map[string]bool),
+	}
+	for _, item := range list.Items {
+		switch item.Type {
+		case "p":
+			f.pubkeys[item.Value] = true
+		case "t":
+			f.hashtags[item.Value] = true
+		case "e":
+			f.threads[item.Value] = true
+		case "word":
+			if len(item.Value) > 2 && strings.HasPrefix(item.Value, "/") && strings.HasSuffix(item.Value, "/") {
+				re, err := regexp.Compile("(?i)" + item.Value[1:len(item.Value)-1])
+				if err == nil {
+					f.regexps = append(f.regexps, re)
+				}
+				continue
+			}
+			f.words = append(f.words, item.Value)
+		}
+	}
+	return f
+}
+
+// muted tells whether ev is hidden by the mute list.
+func (f *muteFilter) muted(ev nostr.Event) bool {
+	if f.pubkeys[ev.PubKey] || f.threads[ev.ID] {
+		return true
+	}
+	for _, tag := range ev.Tags {
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "e":
+			if f.threads[tag[1]] {
+				return true
+			}
+		}
+	}
+	if len(f.hashtags) > 0 {
+		// whole hashtags only: #go doesn't mute #golang
+		for _, tag := range noteHashtags(ev) {
+			if f.hashtags[tag] {
+				return true
+			}
+		}
+	}
+	if len(f.words) == 0 && len(f.regexps) == 0 {
+		return false
+	}
+	content := strings.ToLower(ev.Content)
+	for _, word := range f.words {
+		if strings.Contains(content, word) {
+			return true
+		}
+	}
+	for _, re := range f.regexps {
+		if re.MatchString(ev.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// currentMuteFilter returns the filter for the cached mute list.
+func currentMuteFilter() *muteFilter {
+	muteState.Lock()
+	defer muteState.Unlock()
+	if muteState.filter == nil {
+		muteState.filter = newMuteFilter(muteSpec.load())
+	}
+	return muteState.filter
+}
+
+func setMuteFilter(list nip51List) {
+	muteState.Lock()
+	muteState.filter = newMuteFilter(list)
+	muteState.Unlock()
+}
+
+// filterMuted drops the events hidden by the mute list.
+func filterMuted(events []nostr.Event) []nostr.Event {
+	f := currentMuteFilter()
+	kept := events[:0:0]
+	for _, ev := range events {
+		if !f.muted(ev) {
+			kept = append(kept, ev)
+		}
+	}
+	return kept
+}
+
+// syncMutes picks up the mute list from the relays when it is newer than
+// the cached one. The pool must be initialized.
+func syncMutes() (nip51List, error) {
+	list, err := muteSpec.sync()
+	setMuteFilter(list)
+	return list, err
+}
+
+// updateMutes adds items to or removes them from the mute list and
+// publishes it. The pool must be initialized.
+func updateMutes(items []listItem, mute bool) (*nostr.Event, chan deliveryStatus, error) {
+	list, event, statuses, err := muteSpec.update(items, mute)
+	if err != nil {
+		return nil, nil, err
+	}
+	setMuteFilter(list)
+	return event, statuses, nil
+}
+
+// muteItemFromOpts reads the thing to (un)mute from the command line.
+func muteItemFromOpts(opts docopt.Opts) (listItem, error) {
+	private, _ := opts.Bool("--private")
+	if arg, ok := opts["<pubkey>"].(string); ok && arg != "" {
+		key, err := pubkeyArg(arg)
+		if err != nil {
+			return listItem{}, err
+		}
+		return listItem{Type: "p", Value: key, Private: private}, nil
+	}
+	if tag, err := opts.String("--hashtag"); err == nil && tag != "" {
+		return listItem{Type: "t", Value: tag, Private: private}, nil
+	}
+	if word, err := opts.String("--word"); err == nil && word != "" {
+		if strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/") && len(word) > 2 {
+			if _, err := regexp.Compile(word[1 : len(word)-1]); err != nil {
+				return listItem{}, err
+			}
+		}
+		return listItem{Type: "word", Value: word, Private: private}, nil
+	}
+	if id, err := opts.String("--thread"); err == nil && id != "" {
+		id, err := eventIDArg(id)
+		if err != nil {
+			return listItem{}, err
+		}
+		return listItem{Type: "e", Value: id, Private: private}, nil
+	}
+	return listItem{}, errNothingToMute
+}
+
+func mute(opts docopt.Opts, muting bool) {
+	if config.PrivateKey == "" {
+		log.Println("No private key set.")
+		return
+	}
+	initNostr()
+
+	item, err := muteItemFromOpts(opts)
+	if err == errNothingToMute && muting {
+		listMutes()
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	event, statuses, err := updateMutes([]listItem{item}, muting)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if muting {
+		fmt.Printf("Muted %s.\n", muteLabel(item))
+	} else {
+		fmt.Printf("Unmuted %s.\n", muteLabel(item))
+	}
+	printPublishStatus(event, statuses)
+}
+
+func listMutes() {
+	list, err := syncMutes()
+	if err != nil {
+		log.Println(err)
+	}
+	if len(list.Items) == 0 {
+		fmt.Println("Nothing muted.")
+		return
+	}
+	for _, item := range list.Items {
+		if item.Private {
+			fmt.Println(muteLabel(item), "(private)")
+		} else {
+			fmt.Println(muteLabel(item))
+		}
+	}
+}
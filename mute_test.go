@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestMuteFilterHashtags(t *testing.T) {
+	f := newMuteFilter(nip51List{Items: []listItem{
+		{Type: "t", Value: "go"},
+		{Type: "word", Value: "spam"},
+	}})
+	tests := []struct {
+		content string
+		tags    nostr.Tags
+		want    bool
+	}{
+		{"learning #go today", nil, true},
+		{"learning #Go today", nil, true},
+		{"#go", nil, true},
+		{"learning #golang today", nil, false},
+		{"no tag, just go", nil, false},
+		{"a note", nostr.Tags{{"t", "Go"}}, true},
+		{"a note", nostr.Tags{{"t", "golang"}}, false},
+		{"this is SPAM", nil, true},
+	}
+	for _, tt := range tests {
+		ev := nostr.Event{Content: tt.content, Tags: tt.tags}
+		if got := f.muted(ev); got != tt.want {
+			t.Errorf("muted(%q, %v) = %v, want %v", tt.content, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestListSpecsNormalizeHashtags(t *testing.T) {
+	for _, spec := range []listSpec{muteSpec, bookmarkSpec, interestSpec} {
+		for _, value := range []string{"go", "#Go", " #GO ", "Go\n"} {
+			if got := spec.normalize(listItem{Type: "t", Value: value}); got.Value != "go" {
+				t.Errorf("%s: %q normalized to %q", spec.File, value, got.Value)
+			}
+		}
+	}
+}
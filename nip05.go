@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	nip05CacheFile    = "nip05cache.json"
+	nip05MaxAge       = 24 * time.Hour
+	nip05FailedMaxAge = time.Hour
+	nip05Timeout      = 5 * time.Second
+	nip05MaxBytes     = 64 * 1024 // of a nostr.json read
+	maxNIP05Lookups   = 8         // concurrent requests in checkNIP05s
+)
+
+// httpDoer is the part of *http.Client the NIP-05 lookups need, so tests can
+// substitute the client of an httptest server.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var nip05Client httpDoer = newNIP05Client(nil)
+
+// newNIP05Client returns a client for NIP-05 lookups over transport (the
+// default one when nil). It doesn't follow redirects, which NIP-05 forbids:
+// a redirect is returned as the response and fails the lookup.
+func newNIP05Client(transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   nip05Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nip05Entry is the outcome of looking up one identifier.
+type nip05Entry struct {
+	Pubkey  string    `json:"pubkey,omitempty"` // empty when the lookup failed
+	Relays  []string  `json:"relays,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+var nip05Cache struct {
+	sync.Mutex
+	entries map[string]nip05Entry // identifier -> entry
+}
+
+// splitNIP05 splits name@domain; a bare domain stands for _@domain.
+func splitNIP05(id string) (name, domain string, ok bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	name, domain = "_", id
+	if i := strings.LastIndex(id, "@"); i >= 0 {
+		name, domain = id[:i], id[i+1:]
+	}
+	if name == "" || !strings.Contains(domain, ".") && !strings.Contains(domain, ":") ||
+		strings.ContainsAny(name+domain, " /?#") {
+		return "", "", false
+	}
+	return name, domain, true
+}
+
+// normalizeNIP05 returns id as it is stored in the cache.
+func normalizeNIP05(id string) string {
+	name, domain, ok := splitNIP05(id)
+	if !ok {
+		return ""
+	}
+	return name + "@" + domain
+}
+
+// looksLikeNIP05 tells identifiers apart from npubs and hex keys.
+func looksLikeNIP05(s string) bool {
+	_, _, ok := splitNIP05(s)
+	return ok && strings.Contains(s, "@")
+}
+
+// lookupNIP05 asks the domain of id for its pubkey and relays.
+func lookupNIP05(id string) (nip05Entry, error) {
+	name, domain, ok := splitNIP05(id)
+	if !ok {
+		return nip05Entry{}, fmt.Errorf("invalid identifier %q", id)
+	}
+	u := "https://" + domain + "/.well-known/nostr.json?name=" + url.QueryEscape(name)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nip05Entry{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := nip05Client.Do(req)
+	if err != nil {
+		return nip05Entry{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nip05Entry{}, fmt.Errorf("%s: %s", domain, resp.Status)
+	}
+
+	var result struct {
+		Names  map[string]string   `json:"names"`
+		Relays map[string][]string `json:"relays"`
+	}
+	// any domain can answer; don't read more than a nostr.json needs
+	if err := json.NewDecoder(io.LimitReader(resp.Body, nip05MaxBytes)).Decode(&result); err != nil {
+		return nip05Entry{}, fmt.Errorf("%s: invalid nostr.json: %w", domain, err)
+	}
+	var pubkey string
+	for n, key := range result.Names {
+		if strings.ToLower(n) == name {
+			pubkey = strings.ToLower(key)
+		}
+	}
+	if len(pubkey) != 64 {
+		return nip05Entry{}, fmt.Errorf("%s is not known to %s", name, domain)
+	}
+	entry := nip05Entry{Pubkey: pubkey}
+	for _, relay := range result.Relays[pubkey] {
+		entry.Relays = append(entry.Relays, nostr.NormalizeURL(relay))
+	}
+	return entry, nil
+}
+
+func loadNIP05Cache() {
+	if nip05Cache.entries == nil {
+		nip05Cache.entries = make(map[string]nip05Entry)
+		loadDataFile(nip05CacheFile, &nip05Cache.entries)
+	}
+}
+
+// resolveNIP05 returns the pubkey and relays of id, from the cache while it
+// is fresh. Failed lookups are cached too, for a shorter time.
+func resolveNIP05(id string) (nip05Entry, error) {
+	id = normalizeNIP05(id)
+	if id == "" {
+		return nip05Entry{}, errors.New("invalid identifier")
+	}
+	nip05Cache.Lock()
+	loadNIP05Cache()
+	entry, ok := nip05Cache.entries[id]
+	nip05Cache.Unlock()
+	if ok {
+		maxAge := nip05MaxAge
+		if entry.Pubkey == "" {
+			maxAge = nip05FailedMaxAge
+		}
+		if time.Since(entry.Checked) < maxAge {
+			if entry.Pubkey == "" {
+				return entry, fmt.Errorf("%s could not be verified recently", id)
+			}
+			return entry, nil
+		}
+	}
+
+	entry, err := lookupNIP05(id)
+	entry.Checked = time.Now()
+	nip05Cache.Lock()
+	nip05Cache.entries[id] = entry
+	saveDataFile(nip05CacheFile, nip05Cache.entries)
+	nip05Cache.Unlock()
+	return entry, err
+}
+
+// checkNIP05 tells whether id resolves to pubkey.
+func checkNIP05(id, pubkey string) bool {
+	entry, err := resolveNIP05(id)
+	return err == nil && entry.Pubkey == pubkey
+}
+
+// checkNIP05s verifies the identifiers claimed in a pubkey -> identifier
+// map, a few at a time, so verifiedNIP05 knows about them afterwards.
+func checkNIP05s(claims map[string]string) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxNIP05Lookups)
+	for pubkey, id := range claims {
+		if id == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(pubkey, id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			checkNIP05(id, pubkey)
+			<-sem
+		}(pubkey, id)
+	}
+	wg.Wait()
+}
+
+// verifiedNIP05 returns an identifier the cache has verified for pubkey, or
+// "". It doesn't go to the network.
+func verifiedNIP05(pubkey string) string {
+	nip05Cache.Lock()
+	defer nip05Cache.Unlock()
+	loadNIP05Cache()
+	found := ""
+	for id, entry := range nip05Cache.entries {
+		if entry.Pubkey == pubkey && time.Since(entry.Checked) < nip05MaxAge && (found == "" || id < found) {
+			found = id
+		}
+	}
+	return found
+}
+
+// nip05Mark is appended to author names whose identifier is verified.
+func nip05Mark(pubkey string) string {
+	if verifiedNIP05(pubkey) != "" {
+		return " ✓"
+	}
+	return ""
+}
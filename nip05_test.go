@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckNIP05(t *testing.T) {
+	const (
+		alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+		bob   = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/nostr.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("name") {
+		case "alice":
+			fmt.Fprintf(w, `{"names":{"alice":%q},"relays":{%q:["wss://relay.example"]}}`, alice, alice)
+		case "moved":
+			http.Redirect(w, r, "/.well-known/nostr.json?name=alice", http.StatusFound)
+		case "broken":
+			fmt.Fprint(w, `{"names":{"broken":`)
+		case "huge":
+			fmt.Fprintf(w, `{"padding":%q,"names":{"huge":%q}}`, strings.Repeat("x", nip05MaxBytes), alice)
+		default:
+			fmt.Fprint(w, `{"names":{}}`)
+		}
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	domain := strings.TrimPrefix(server.URL, "https://")
+
+	defer func(client httpDoer, dir string) {
+		nip05Client = client
+		config.DataDir = dir
+		nip05Cache.entries = nil
+	}(nip05Client, config.DataDir)
+	nip05Client = newNIP05Client(server.Client().Transport)
+	config.DataDir = t.TempDir()
+	nip05Cache.entries = nil
+
+	tests := []struct {
+		name, pubkey string
+		want         bool
+	}{
+		{"alice", alice, true},
+		{"alice", bob, false},   // someone else's identifier
+		{"moved", alice, false}, // redirects are not followed
+		{"broken", alice, false},
+		{"huge", alice, false}, // bodies are cut off
+		{"nobody", alice, false},
+	}
+	for _, tt := range tests {
+		id := tt.name + "@" + domain
+		if got := checkNIP05(id, tt.pubkey); got != tt.want {
+			t.Errorf("checkNIP05(%s, %s) = %v, want %v", id, shorten(tt.pubkey), got, tt.want)
+		}
+	}
+
+	entry, err := lookupNIP05("alice@" + domain)
+	if err != nil || len(entry.Relays) != 1 || entry.Relays[0] != "wss://relay.example" {
+		t.Errorf("lookup of alice: %+v, %v", entry, err)
+	}
+	if _, err := lookupNIP05("moved@" + domain); err == nil || !strings.Contains(err.Error(), "302") {
+		t.Errorf("lookup of a redirect: %v, want the 302 as error", err)
+	}
+	if _, err := lookupNIP05("broken@" + domain); err == nil || !strings.Contains(err.Error(), "invalid nostr.json") {
+		t.Errorf("lookup of malformed JSON: %v", err)
+	}
+	if got := verifiedNIP05(alice); got != "alice@"+domain {
+		t.Errorf("verifiedNIP05(alice) = %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const nip05NamesFile = "nip05.json"
+
+// nip05Name is one identifier we serve.
+type nip05Name struct {
+	Pubkey string   `json:"pubkey"`
+	Relays []string `json:"relays,omitempty"`
+}
+
+func loadNIP05Names() (map[string]nip05Name, error) {
+	names := make(map[string]nip05Name)
+	err := loadDataFile(nip05NamesFile, &names)
+	return names, err
+}
+
+// validNIP05Name accepts the local-part characters NIP-05 allows.
+func validNIP05Name(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// nostrJSON builds the nostr.json document for names, or only for the
+// requested name when it is not empty.
+func nostrJSON(names map[string]nip05Name, requested string) interface{} {
+	doc := struct {
+		Names  map[string]string   `json:"names"`
+		Relays map[string][]string `json:"relays,omitempty"`
+	}{Names: make(map[string]string), Relays: make(map[string][]string)}
+	for name, entry := range names {
+		if requested != "" && name != requested {
+			continue
+		}
+		doc.Names[name] = entry.Pubkey
+		if len(entry.Relays) > 0 {
+			doc.Relays[entry.Pubkey] = entry.Relays
+		}
+	}
+	return doc
+}
+
+func nip05(opts docopt.Opts) {
+	switch {
+	case opts["add"].(bool):
+		addNIP05Name(opts)
+	case opts["remove"].(bool):
+		removeNIP05Name(opts)
+	case opts["serve"].(bool):
+		serveNIP05(opts)
+	default:
+		listNIP05Names()
+	}
+}
+
+func addNIP05Name(opts docopt.Opts) {
+	name := strings.ToLower(opts["<name>"].(string))
+	if !validNIP05Name(name) {
+		log.Println("Names may only contain a-z, 0-9, '-', '_' and '.'.")
+		return
+	}
+	key := nip19.TranslatePublicKey(opts["<pubkey>"].(string))
+	if key == "" {
+		log.Println("Invalid pubkey.")
+		return
+	}
+	names, err := loadNIP05Names()
+	if err != nil {
+		log.Printf("Can't read %s: %s.\n", nip05NamesFile, err)
+		return
+	}
+	entry := nip05Name{Pubkey: key}
+	for _, url := range opts["--relay"].([]string) {
+		entry.Relays = append(entry.Relays, nostr.NormalizeURL(url))
+	}
+	names[name] = entry
+	if err := saveDataFile(nip05NamesFile, names); err != nil {
+		log.Printf("Can't write %s: %s.\n", nip05NamesFile, err)
+		return
+	}
+	fmt.Printf("Added %s -> %s.\n", name, key)
+}
+
+func removeNIP05Name(opts docopt.Opts) {
+	name := strings.ToLower(opts["<name>"].(string))
+	names, err := loadNIP05Names()
+	if err != nil {
+		log.Printf("Can't read %s: %s.\n", nip05NamesFile, err)
+		return
+	}
+	if _, ok := names[name]; !ok {
+		log.Printf("%s is not in %s.\n", name, nip05NamesFile)
+		return
+	}
+	delete(names, name)
+	if err := saveDataFile(nip05NamesFile, names); err != nil {
+		log.Printf("Can't write %s: %s.\n", nip05NamesFile, err)
+		return
+	}
+	fmt.Printf("Removed %s.\n", name)
+}
+
+func listNIP05Names() {
+	names, err := loadNIP05Names()
+	if err != nil {
+		log.Printf("Can't read %s: %s.\n", nip05NamesFile, err)
+		return
+	}
+	if len(names) == 0 {
+		fmt.Println("No names yet, add one with 'noscl nip05 add'.")
+		return
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		entry := names[name]
+		fmt.Println(name, entry.Pubkey, strings.Join(entry.Relays, " "))
+	}
+}
+
+// serveNIP05 serves nip05Handler.
+func serveNIP05(opts docopt.Opts) {
+	addr, _ := opts.String("--listen")
+	if addr == "" {
+		addr = ":8080"
+	}
+	fmt.Printf("Serving /.well-known/nostr.json on %s.\n", addr)
+	if err := http.ListenAndServe(addr, nip05Handler()); err != nil {
+		log.Println(err)
+	}
+}
+
+// nip05Handler serves /.well-known/nostr.json from the names file, which is
+// read again on every request so add and remove apply right away.
+func nip05Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/nostr.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		switch r.Method {
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodGet, http.MethodHead:
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		names, err := loadNIP05Names()
+		if err != nil {
+			log.Printf("Can't read %s: %s.\n", nip05NamesFile, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(nostrJSON(names, strings.ToLower(r.URL.Query().Get("name"))))
+	})
+	return mux
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNIP05Handler(t *testing.T) {
+	const (
+		alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+		bob   = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	)
+	defer func(dir string) { config.DataDir = dir }(config.DataDir)
+	config.DataDir = t.TempDir()
+	err := saveDataFile(nip05NamesFile, map[string]nip05Name{
+		"alice": {Pubkey: alice, Relays: []string{"wss://relay.example"}},
+		"bob":   {Pubkey: bob},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(nip05Handler())
+	defer server.Close()
+
+	type doc struct {
+		Names  map[string]string   `json:"names"`
+		Relays map[string][]string `json:"relays"`
+	}
+	tests := []struct {
+		query string
+		want  doc
+	}{
+		{"", doc{
+			Names:  map[string]string{"alice": alice, "bob": bob},
+			Relays: map[string][]string{alice: {"wss://relay.example"}},
+		}},
+		{"?name=alice", doc{
+			Names:  map[string]string{"alice": alice},
+			Relays: map[string][]string{alice: {"wss://relay.example"}},
+		}},
+		{"?name=ALICE", doc{
+			Names:  map[string]string{"alice": alice},
+			Relays: map[string][]string{alice: {"wss://relay.example"}},
+		}},
+		// no relays key without relays
+		{"?name=bob", doc{Names: map[string]string{"bob": bob}}},
+		{"?name=carol", doc{Names: map[string]string{}}},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + "/.well-known/nostr.json" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got doc
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil {
+			t.Errorf("%q: %s", tt.query, err)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: %s %+v, want %+v", tt.query, resp.Status, got, tt.want)
+		}
+		if cors := resp.Header.Get("Access-Control-Allow-Origin"); cors != "*" {
+			t.Errorf("%q: Access-Control-Allow-Origin %q", tt.query, cors)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%q: Content-Type %q", tt.query, ct)
+		}
+	}
+
+	// browsers ask before fetching
+	req, _ := http.NewRequest(http.MethodOptions, server.URL+"/.well-known/nostr.json", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("OPTIONS: %s, CORS %q", resp.Status, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+	resp, err = http.Post(server.URL+"/.well-known/nostr.json", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: %s", resp.Status)
+	}
+}
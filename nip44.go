@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/hkdf"
+)
+
+// NIP-44 version 2 encryption, used for the private items of NIP-51 lists.
+
+const (
+	nip44Version    = 2
+	nip44MinPlain   = 1
+	nip44MaxPlain   = 65535
+	nip44MinPayload = 1 + 32 + 2 + 32 + 32 // version, nonce, smallest ciphertext, mac
+)
+
+// nip44ConversationKey derives the key shared by privateKey (raw bytes, as
+// in config.PrivateKey) and pubkey.
+func nip44ConversationKey(privateKey string, pubkey string) ([]byte, error) {
+	shared, err := nip04.ComputeSharedSecret(hex.EncodeToString([]byte(privateKey)), pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Extract(sha256.New, shared, []byte("nip44-v2")), nil
+}
+
+// nip44PaddedLen rounds n up to the padded plaintext length.
+func nip44PaddedLen(n int) int {
+	if n <= 32 {
+		return 32
+	}
+	next := 1 << bits.Len(uint(n-1))
+	chunk := 32
+	if next > 256 {
+		chunk = next / 8
+	}
+	return chunk * ((n-1)/chunk + 1)
+}
+
+func nip44MessageKeys(conversationKey, nonce []byte) (key, chachaNonce, hmacKey []byte, err error) {
+	keys := make([]byte, 76)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, conversationKey, nonce), keys); err != nil {
+		return nil, nil, nil, err
+	}
+	return keys[:32], keys[32:44], keys[44:], nil
+}
+
+func nip44Mac(hmacKey, nonce, ciphertext []byte) []byte {
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(nonce)
+	mac.Write(ciphertext)
+	return mac.Sum(nil)
+}
+
+func nip44Encrypt(plaintext string, conversationKey []byte) (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return nip44EncryptWithNonce(plaintext, conversationKey, nonce)
+}
+
+func nip44EncryptWithNonce(plaintext string, conversationKey, nonce []byte) (string, error) {
+	if len(plaintext) < nip44MinPlain || len(plaintext) > nip44MaxPlain {
+		return "", errors.New("nip44: plaintext must be 1 to 65535 bytes")
+	}
+	key, chachaNonce, hmacKey, err := nip44MessageKeys(conversationKey, nonce)
+	if err != nil {
+		return "", err
+	}
+	padded := make([]byte, 2+nip44PaddedLen(len(plaintext)))
+	binary.BigEndian.PutUint16(padded, uint16(len(plaintext)))
+	copy(padded[2:], plaintext)
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(key, chachaNonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(padded))
+	cipher.XORKeyStream(ciphertext, padded)
+
+	payload := append([]byte{nip44Version}, nonce...)
+	payload = append(payload, ciphertext...)
+	payload = append(payload, nip44Mac(hmacKey, nonce, ciphertext)...)
+	return base64.StdEncoding.EncodeToString(payload), nil
+}
+
+func nip44Decrypt(payload string, conversationKey []byte) (string, error) {
+	if payload == "" || payload[0] == '#' {
+		return "", errors.New("nip44: unknown version")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < nip44MinPayload || data[0] != nip44Version {
+		return "", errors.New("nip44: unknown version or invalid payload")
+	}
+	nonce := data[1:33]
+	ciphertext := data[33 : len(data)-32]
+	key, chachaNonce, hmacKey, err := nip44MessageKeys(conversationKey, nonce)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(nip44Mac(hmacKey, nonce, ciphertext), data[len(data)-32:]) {
+		return "", errors.New("nip44: invalid mac")
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(key, chachaNonce)
+	if err != nil {
+		return "", err
+	}
+	padded := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(padded, ciphertext)
+	n := int(binary.BigEndian.Uint16(padded))
+	if n < nip44MinPlain || len(padded) != 2+nip44PaddedLen(n) {
+		return "", errors.New("nip44: invalid padding")
+	}
+	return string(padded[2 : 2+n]), nil
+}
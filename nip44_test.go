@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Test vectors from the NIP-44 v2 specification (nip44.vectors.json).
+
+func hexBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNIP44ConversationKey(t *testing.T) {
+	tests := []struct {
+		sec1, pub2, want string
+	}{
+		{
+			"315e59ff51cb9209768cf7da80791ddcaae56ac9775eb25b6dee1234bc5d2268",
+			"c2f9d9948dc8c7c38321e4b85c8558872eafa0641cd269db76848a6073e69133",
+			"3dfef0ce2a4d80a25e7a328accf73448ef67096f65f79588e358d9a0eb9013f1",
+		},
+		{
+			"a1e37752c9fdc1273be53f68c5f74be7c8905728e8de75800b94262f9497c86e",
+			"03bb7947065dde12ba991ea045132581d0954f042c84e06d8c00066e23c1a800",
+			"4d14f36e81b8452128da64fe6f1eae873baae2f444b02c950b90e43553f2178b",
+		},
+		{
+			"98a5902fd67518a0c900f0fb62158f278f94a21d6f9d33d30cd3091195500311",
+			"aae65c15f98e5e677b5050de82e3aba47a6fe49b3dab7863cf35d9478ba9f7d1",
+			"9c00b769d5f54d02bf175b7284a1cbd28b6911b06cda6666b2243561ac96bad7",
+		},
+	}
+	for _, tt := range tests {
+		key, err := nip44ConversationKey(string(hexBytes(t, tt.sec1)), tt.pub2)
+		if err != nil {
+			t.Errorf("%s: %s", tt.sec1, err)
+			continue
+		}
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Errorf("%s: conversation key %s, want %s", tt.sec1, got, tt.want)
+		}
+	}
+}
+
+func TestNIP44PaddedLen(t *testing.T) {
+	tests := []struct{ n, want int }{
+		{1, 32}, {16, 32}, {32, 32}, {33, 64}, {37, 64}, {45, 64}, {49, 64},
+		{64, 64}, {65, 96}, {100, 128}, {111, 128}, {200, 224}, {250, 256},
+		{320, 320}, {383, 384}, {384, 384}, {400, 448}, {500, 512},
+		{512, 512}, {515, 640}, {700, 768}, {800, 896}, {900, 1024},
+		{1020, 1024}, {65536, 65536},
+	}
+	for _, tt := range tests {
+		if got := nip44PaddedLen(tt.n); got != tt.want {
+			t.Errorf("nip44PaddedLen(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNIP44EncryptDecrypt(t *testing.T) {
+	tests := []struct {
+		sec1, sec2, conversationKey, nonce, plaintext, payload string
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000002",
+			"c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"a",
+			"AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb",
+		},
+		{
+			"0000000000000000000000000000000000000000000000000000000000000002",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d",
+			"f00000000000000000000000000000f00000000000000000000000000000000f",
+			"🍕🫃",
+			"AvAAAAAAAAAAAAAAAAAAAPAAAAAAAAAAAAAAAAAAAAAPSKSK6is9ngkX2+cSq85Th16oRTISAOfhStnixqZziKMDvB0QQzgFZdjLTPicCJaV8nDITO+QfaQ61+KbWQIOO2Yj",
+		},
+		{
+			"5c0c523f52a5b6fad39ed2403092df8cebc36318b39383bca6c00808626fab3a",
+			"4b22aa260e4acb7021e32f38a6cdf4b673c6a277755bfce287e370c924dc936d",
+			"3e2b52a63be47d34fe0a80e34e73d436d6963bc8f39827f327057a9986c20a45",
+			"b635236c42db20f021bb8d1cdff5ca75dd1a0cc72ea742ad750f33010b24f73b",
+			"表ポあA鷗ŒéＢ逍Üßªąñ丂㐀𠀀",
+			"ArY1I2xC2yDwIbuNHN/1ynXdGgzHLqdCrXUPMwELJPc7s7JqlCMJBAIIjfkpHReBPXeoMCyuClwgbT419jUWU1PwaNl4FEQYKCDKVJz+97Mp3K+Q2YGa77B6gpxB/lr1QgoqpDf7wDVrDmOqGoiPjWDqy8KzLueKDcm9BVP8xeTJIxs=",
+		},
+	}
+	for _, tt := range tests {
+		// both sides derive the same key
+		pub2 := getPubKey(string(hexBytes(t, tt.sec2)))
+		key, err := nip44ConversationKey(string(hexBytes(t, tt.sec1)), pub2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(key); got != tt.conversationKey {
+			t.Errorf("%q: conversation key %s, want %s", tt.plaintext, got, tt.conversationKey)
+			continue
+		}
+		payload, err := nip44EncryptWithNonce(tt.plaintext, key, hexBytes(t, tt.nonce))
+		if err != nil {
+			t.Errorf("%q: %s", tt.plaintext, err)
+			continue
+		}
+		if payload != tt.payload {
+			t.Errorf("%q: payload %s, want %s", tt.plaintext, payload, tt.payload)
+		}
+		plaintext, err := nip44Decrypt(tt.payload, key)
+		if err != nil || plaintext != tt.plaintext {
+			t.Errorf("decrypting %q: got %q, %v", tt.plaintext, plaintext, err)
+		}
+	}
+}
+
+func TestNIP44EncryptLength(t *testing.T) {
+	key := hexBytes(t, "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	for _, n := range []int{0, 65536} {
+		if _, err := nip44Encrypt(strings.Repeat("a", n), key); err == nil {
+			t.Errorf("encrypting %d bytes: no error", n)
+		}
+	}
+}
+
+// sealNIP44 encrypts padded as it is and adds a valid mac, so that
+// payloads with bad padding can be built.
+func sealNIP44(t *testing.T, key, nonce, padded []byte) string {
+	t.Helper()
+	msgKey, chachaNonce, hmacKey, err := nip44MessageKeys(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher, err := chacha20.NewUnauthenticatedCipher(msgKey, chachaNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := make([]byte, len(padded))
+	cipher.XORKeyStream(ciphertext, padded)
+	payload := append([]byte{nip44Version}, nonce...)
+	payload = append(payload, ciphertext...)
+	payload = append(payload, nip44Mac(hmacKey, nonce, ciphertext)...)
+	return base64.StdEncoding.EncodeToString(payload)
+}
+
+func TestNIP44DecryptInvalid(t *testing.T) {
+	key := hexBytes(t, "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	nonce := hexBytes(t, "0000000000000000000000000000000000000000000000000000000000000001")
+	valid := "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+	raw, _ := base64.StdEncoding.DecodeString(valid)
+	tamper := func(i int) string {
+		b := append([]byte(nil), raw...)
+		b[i] ^= 1
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	padding := func(n, size int) string {
+		padded := make([]byte, 2+size)
+		binary.BigEndian.PutUint16(padded, uint16(n))
+		return sealNIP44(t, key, nonce, padded)
+	}
+
+	tests := []struct {
+		note    string
+		key     string
+		payload string
+	}{
+		{
+			"unknown encryption version",
+			"ca2527a037347b91bea0c8a30fc8d9600ffd81ec00038671e3a0f0cb0fc9f642",
+			"#Atqupco0WyaOW2IGDKcshwxI9xO8HgD/P8Ddt46CbxDbrhdG8VmJZE0UICD06CUvEvdnr1cp1fiMtlM/GrE92xAc1K5odTpCzUB+mjXgbaqtntBUbTToSUoT0ovrlPwzGjyp",
+		},
+		{
+			"unknown encryption version 0",
+			"36f04e558af246352dcf73b692fbd3646a2207bd8abd4b1cd26b234db84d9481",
+			"AK1AjUvoYW3IS7C/BGRUoqEC7ayTfDUgnEPNeWTF/reBZFaha6EAIRueE9D1B1RuoiuFScC0Q94yjIuxZD3JStQtE8JMNacWFs9rlYP+ZydtHhRucp+lxfdvFlaGV/sQlqZz",
+		},
+		{
+			"invalid base64",
+			"ca2527a037347b91bea0c8a30fc8d9600ffd81ec00038671e3a0f0cb0fc9f642",
+			"Atфupco0WyaOW2IGDKcshwxI9xO8HgD/P8Ddt46CbxDbrhdG8VmJZE0UICD06CUvEvdnr1cp1fiMtlM/GrE92xAc1K5odTpCzUB+mjXgbaqtntBUbTToSUoT0ovrlPwzGjyp",
+		},
+		// built from the first encrypt vector
+		{"empty payload", "", ""},
+		{"too short", "", valid[:128]},
+		{"unknown version 1", "", "AQ" + valid[2:]},
+		{"invalid mac: ciphertext changed", "", tamper(40)},
+		{"invalid mac: nonce changed", "", tamper(5)},
+		{"invalid mac: mac changed", "", tamper(len(raw) - 1)},
+		{"invalid padding: zero length", "", padding(0, 32)},
+		{"invalid padding: length past the end", "", padding(40, 32)},
+		{"invalid padding: too much padding", "", padding(1, 64)},
+	}
+	for _, tt := range tests {
+		k := key
+		if tt.key != "" {
+			k = hexBytes(t, tt.key)
+		}
+		if plaintext, err := nip44Decrypt(tt.payload, k); err == nil {
+			t.Errorf("%s: decrypted to %q", tt.note, plaintext)
+		}
+	}
+	// the padded payloads above only fail on their padding
+	if plaintext, err := nip44Decrypt(padding(1, 32), key); err != nil || plaintext != "\x00" {
+		t.Errorf("valid padding: got %q, %v", plaintext, err)
+	}
+}
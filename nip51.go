@@ -0,0 +1,375 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+// listItem is one entry of a NIP-51 list: a tag name ("p", "e", "t", ...)
+// and its value, either public or in the encrypted content.
+type listItem struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Private bool   `json:"private,omitempty"`
+}
+
+// nip51List is one of our replaceable NIP-51 lists as cached in the datadir.
+// Other holds the tags of types the list doesn't handle, so that publishing
+// it again keeps what other clients put there.
+type nip51List struct {
+	Items     []listItem `json:"items"`
+	Other     []listTag  `json:"other,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// listTag is a tag of a list kept as it was.
+type listTag struct {
+	Tag     []string `json:"tag"`
+	Private bool     `json:"private,omitempty"`
+}
+
+// listSpec describes a list kind: where it is cached, which tags it keeps
+// and how values are normalized. D is the "d" tag of parameterized
+// replaceable lists such as follow sets.
+type listSpec struct {
+	Kind      int
+	File      string
+	D         string
+	Types     []string
+	Normalize func(listItem) listItem
+}
+
+func (item listItem) String() string {
+	switch item.Type {
+	case "p":
+		npub, _ := nip19.EncodePublicKey(item.Value, "")
+		if f, ok := config.Following[item.Value]; ok && f.Name != "" {
+			return "pubkey " + npub + " (" + f.Name + ")"
+		}
+		return "pubkey " + npub
+	case "e":
+		return "note " + item.Value
+	case "t":
+		return "hashtag #" + item.Value
+	case "r":
+		return "url " + item.Value
+	case "a":
+		return "address " + item.Value
+	}
+	return item.Type + " " + item.Value
+}
+
+func (spec listSpec) normalize(item listItem) listItem {
+	item.Value = strings.TrimSpace(item.Value)
+	if spec.Normalize != nil {
+		item = spec.Normalize(item)
+	}
+	return item
+}
+
+func (spec listSpec) handles(typ string) bool {
+	for _, t := range spec.Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTags splits tags into the items of the list and the tags it doesn't
+// handle.
+func (spec listSpec) parseTags(tags [][]string, private bool) ([]listItem, []listTag) {
+	var items []listItem
+	var other []listTag
+	for _, tag := range tags {
+		switch {
+		case len(tag) == 0:
+		case spec.handles(tag[0]):
+			if len(tag) >= 2 && tag[1] != "" {
+				items = append(items, spec.normalize(listItem{Type: tag[0], Value: tag[1], Private: private}))
+			}
+		case tag[0] == "d" && !private:
+			// event writes spec.D
+		default:
+			other = append(other, listTag{Tag: tag, Private: private})
+		}
+	}
+	return items, other
+}
+
+// parse reads the public tags of a list event and, when it is ours, the
+// encrypted private items. Older clients encrypted those with NIP-04.
+func (spec listSpec) parse(ev *nostr.Event) (nip51List, error) {
+	list := nip51List{CreatedAt: ev.CreatedAt}
+	tags := make([][]string, len(ev.Tags))
+	for i, tag := range ev.Tags {
+		tags[i] = tag
+	}
+	list.Items, list.Other = spec.parseTags(tags, false)
+	if ev.Content == "" || config.PrivateKey == "" || ev.PubKey != getPubKey(config.PrivateKey) {
+		return list, nil
+	}
+
+	var plain string
+	var err error
+	if strings.Contains(ev.Content, "?iv=") {
+		var secret []byte
+		secret, err = nip04.ComputeSharedSecret(hex.EncodeToString([]byte(config.PrivateKey)), ev.PubKey)
+		if err == nil {
+			plain, err = nip04.Decrypt(ev.Content, secret)
+		}
+	} else {
+		var key []byte
+		key, err = nip44ConversationKey(config.PrivateKey, ev.PubKey)
+		if err == nil {
+			plain, err = nip44Decrypt(ev.Content, key)
+		}
+	}
+	if err != nil {
+		return list, fmt.Errorf("can't decrypt the private items: %w", err)
+	}
+	var private [][]string
+	if err := json.Unmarshal([]byte(plain), &private); err != nil {
+		return list, fmt.Errorf("invalid private items: %w", err)
+	}
+	items, other := spec.parseTags(private, true)
+	list.Items = append(list.Items, items...)
+	list.Other = append(list.Other, other...)
+	return list, nil
+}
+
+// event builds the list event, encrypting the private items to ourselves.
+func (spec listSpec) event(list nip51List) (*nostr.Event, error) {
+	pubkey := getPubKey(config.PrivateKey)
+	ev := &nostr.Event{
+		PubKey:    pubkey,
+		CreatedAt: time.Now(),
+		Kind:      spec.Kind,
+		Tags:      nostr.Tags{},
+	}
+	if spec.D != "" {
+		ev.Tags = append(ev.Tags, nostr.Tag{"d", spec.D})
+	}
+	var private [][]string
+	for _, tag := range list.Other {
+		if tag.Private {
+			private = append(private, tag.Tag)
+		} else {
+			ev.Tags = append(ev.Tags, nostr.Tag(tag.Tag))
+		}
+	}
+	for _, item := range list.Items {
+		if item.Private {
+			private = append(private, []string{item.Type, item.Value})
+		} else {
+			ev.Tags = append(ev.Tags, nostr.Tag{item.Type, item.Value})
+		}
+	}
+	if len(private) > 0 {
+		plain, _ := json.Marshal(private)
+		key, err := nip44ConversationKey(config.PrivateKey, pubkey)
+		if err != nil {
+			return nil, err
+		}
+		if ev.Content, err = nip44Encrypt(string(plain), key); err != nil {
+			return nil, err
+		}
+	}
+	return ev, nil
+}
+
+// listOps serializes syncing and updating lists, so a sync that started
+// earlier can't save an older list over a fresh update.
+var listOps sync.Mutex
+
+// lists read from the datadir, so views can look entries up cheaply
+var listCache struct {
+	sync.Mutex
+	lists map[string]nip51List
+}
+
+func (spec listSpec) load() nip51List {
+	path := filepath.Join(config.DataDir, spec.File)
+	listCache.Lock()
+	defer listCache.Unlock()
+	if list, ok := listCache.lists[path]; ok {
+		return list
+	}
+	var list nip51List
+	if err := loadDataFile(spec.File, &list); err != nil {
+		log.Printf("Can't read %s: %s.\n", spec.File, err)
+	}
+	if listCache.lists == nil {
+		listCache.lists = make(map[string]nip51List)
+	}
+	listCache.lists[path] = list
+	return list
+}
+
+func (spec listSpec) save(list nip51List) {
+	if err := saveDataFile(spec.File, list); err != nil {
+		log.Printf("Can't write %s: %s.\n", spec.File, err)
+	}
+	listCache.Lock()
+	if listCache.lists == nil {
+		listCache.lists = make(map[string]nip51List)
+	}
+	listCache.lists[filepath.Join(config.DataDir, spec.File)] = list
+	listCache.Unlock()
+}
+
+// has tells whether the cached list contains typ:value.
+func (spec listSpec) has(typ, value string) bool {
+	for _, item := range spec.load().Items {
+		if item.Type == typ && item.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sync replaces the cached list with ours from the relays when that is
+// newer. It fails when no relay replied. The pool must be initialized.
+func (spec listSpec) sync() (nip51List, error) {
+	listOps.Lock()
+	defer listOps.Unlock()
+	return spec.syncLocked()
+}
+
+func (spec listSpec) syncLocked() (nip51List, error) {
+	list := spec.load()
+	if config.PrivateKey == "" {
+		return list, nil
+	}
+	pubkey := getPubKey(config.PrivateKey)
+	newest, err := queryNewest([]string{pubkey}, spec.Kind)
+	if err != nil {
+		// the relays may well have a newer list: don't publish over it
+		return list, err
+	}
+	ev, ok := newest[pubkey]
+	if !ok || !ev.CreatedAt.After(list.CreatedAt) {
+		return list, nil
+	}
+	remote, err := spec.parse(&ev)
+	if err != nil {
+		return list, err
+	}
+	spec.save(remote)
+	return remote, nil
+}
+
+// update adds items to or removes them from the newest list and publishes
+// the result. The pool must be initialized.
+func (spec listSpec) update(items []listItem, add bool) (nip51List, *nostr.Event, chan deliveryStatus, error) {
+	if config.PrivateKey == "" {
+		return nip51List{}, nil, nil, errors.New("no private key set")
+	}
+	listOps.Lock()
+	defer listOps.Unlock()
+	list, err := spec.syncLocked()
+	if err != nil {
+		// publishing now would drop the private items we can't read
+		return list, nil, nil, err
+	}
+	if list, err = spec.apply(list, items, add); err != nil {
+		return list, nil, nil, err
+	}
+	event, statuses, err := spec.publish(list)
+	if err != nil {
+		return list, nil, nil, err
+	}
+	list.CreatedAt = event.CreatedAt
+	spec.save(list)
+	return list, event, statuses, nil
+}
+
+// apply adds items to list or removes them from it.
+func (spec listSpec) apply(list nip51List, items []listItem, add bool) (nip51List, error) {
+	// match by type and value; the private flag comes from items
+	type itemKey struct{ typ, value string }
+	changes := make(map[itemKey]listItem)
+	var order []itemKey
+	for _, item := range items {
+		item = spec.normalize(item)
+		key := itemKey{item.Type, item.Value}
+		if _, ok := changes[key]; !ok {
+			order = append(order, key)
+		}
+		changes[key] = item
+	}
+	var kept []listItem
+	found := false
+	for _, existing := range list.Items {
+		key := itemKey{existing.Type, existing.Value}
+		item, ok := changes[key]
+		if !ok {
+			kept = append(kept, existing)
+			continue
+		}
+		found = true
+		delete(changes, key)
+		if add {
+			kept = append(kept, item)
+		}
+	}
+	if !add && !found {
+		return list, errors.New("not in the list")
+	}
+	if add {
+		for _, key := range order {
+			if item, ok := changes[key]; ok {
+				kept = append(kept, item)
+			}
+		}
+	}
+	list.Items = kept
+	return list, nil
+}
+
+// publish signs and sends list. The pool must be initialized.
+func (spec listSpec) publish(list nip51List) (*nostr.Event, chan deliveryStatus, error) {
+	ev, err := spec.event(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publishEvent(ev)
+}
+
+// eventIDArg accepts a hex event id or a note1 code.
+func eventIDArg(id string) (string, error) {
+	if raw, prefix, err := nip19.Decode(id); err == nil && prefix == "note" {
+		id = hex.EncodeToString(raw)
+	}
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 64 {
+		return "", errors.New("invalid event id")
+	}
+	return strings.ToLower(id), nil
+}
+
+// pubkeyArg accepts a hex pubkey, an npub or a NIP-05 identifier.
+func pubkeyArg(arg string) (string, error) {
+	if looksLikeNIP05(arg) {
+		entry, err := resolveNIP05(arg)
+		if err != nil {
+			return "", err
+		}
+		return entry.Pubkey, nil
+	}
+	key := nip19.TranslatePublicKey(arg)
+	if _, err := hex.DecodeString(key); err != nil || len(key) != 64 {
+		return "", errors.New("invalid pubkey")
+	}
+	return strings.ToLower(key), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// testPrivateKey is the secret key 1, raw as config keeps it.
+var testPrivateKey = string(append(make([]byte, 31), 1))
+
+func TestListSpecKeepsOtherTags(t *testing.T) {
+	config.PrivateKey = testPrivateKey
+	defer func() { config.PrivateKey = "" }()
+	spec := listSpec{Kind: KindFollowSet, D: "friends", Types: []string{"p"}}
+	key := getPubKey(testPrivateKey)
+
+	private := nip51List{
+		Items: []listItem{{Type: "p", Value: key, Private: true}},
+		Other: []listTag{{Tag: []string{"word", "spoiler"}, Private: true}},
+	}
+	encrypted, err := spec.event(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &nostr.Event{
+		PubKey: key,
+		Kind:   KindFollowSet,
+		Tags: nostr.Tags{
+			{"d", "friends"},
+			{"title", "Friends"},
+			{"p", key, "wss://relay.example", "me"},
+			{"p", ""},
+			{"image", "https://example.com/a.png", "256x256"},
+			{"emoji", "soapbox", "https://example.com/soapbox.png"},
+		},
+		Content: encrypted.Content,
+	}
+	list, err := spec.parse(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantItems := []listItem{
+		{Type: "p", Value: key},
+		{Type: "p", Value: key, Private: true},
+	}
+	if !reflect.DeepEqual(list.Items, wantItems) {
+		t.Errorf("items = %v, want %v", list.Items, wantItems)
+	}
+	wantOther := []listTag{
+		{Tag: []string{"title", "Friends"}},
+		{Tag: []string{"image", "https://example.com/a.png", "256x256"}},
+		{Tag: []string{"emoji", "soapbox", "https://example.com/soapbox.png"}},
+		{Tag: []string{"word", "spoiler"}, Private: true},
+	}
+	if !reflect.DeepEqual(list.Other, wantOther) {
+		t.Errorf("other = %v, want %v", list.Other, wantOther)
+	}
+
+	out, err := spec.event(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTags := nostr.Tags{
+		{"d", "friends"},
+		{"title", "Friends"},
+		{"image", "https://example.com/a.png", "256x256"},
+		{"emoji", "soapbox", "https://example.com/soapbox.png"},
+		{"p", key},
+	}
+	if !reflect.DeepEqual(out.Tags, wantTags) {
+		t.Errorf("tags = %v, want %v", out.Tags, wantTags)
+	}
+	out.PubKey = key
+	again, err := spec.parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Other, list.Other) {
+		t.Errorf("other after publishing = %v, want %v", again.Other, list.Other)
+	}
+}
+
+func TestListSpecApply(t *testing.T) {
+	spec := listSpec{
+		Kind:  KindFollowSet,
+		Types: []string{"p", "t"},
+		Normalize: func(item listItem) listItem {
+			item.Value = strings.ToLower(item.Value)
+			return item
+		},
+	}
+	a := listItem{Type: "p", Value: "aa"}
+	b := listItem{Type: "p", Value: "bb"}
+	tag := listItem{Type: "t", Value: "go"}
+	tests := []struct {
+		note  string
+		items []listItem
+		add   bool
+		want  []listItem
+		err   bool
+	}{
+		{"add new at the end", []listItem{b}, true, []listItem{a, tag, b}, false},
+		{"add normalized", []listItem{{Type: "t", Value: " Nostr "}}, true,
+			[]listItem{a, tag, {Type: "t", Value: "nostr"}}, false},
+		{"add present keeps place", []listItem{tag, a}, true, []listItem{a, tag}, false},
+		{"add twice once", []listItem{b, b}, true, []listItem{a, tag, b}, false},
+		{"make private", []listItem{{Type: "t", Value: "GO", Private: true}}, true,
+			[]listItem{a, {Type: "t", Value: "go", Private: true}}, false},
+		{"same value other type", []listItem{{Type: "t", Value: "aa"}}, true,
+			[]listItem{a, tag, {Type: "t", Value: "aa"}}, false},
+		{"remove", []listItem{{Type: "p", Value: "AA"}}, false, []listItem{tag}, false},
+		{"remove private by value", []listItem{{Type: "t", Value: "go", Private: true}}, false, []listItem{a}, false},
+		{"remove some missing", []listItem{a, b}, false, []listItem{tag}, false},
+		{"remove missing", []listItem{b}, false, []listItem{a, tag}, true},
+	}
+	for _, tt := range tests {
+		list := nip51List{Items: []listItem{a, tag}}
+		got, err := spec.apply(list, tt.items, tt.add)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error %v", tt.note, err)
+		}
+		if !reflect.DeepEqual(got.Items, tt.want) {
+			t.Errorf("%s: items = %v, want %v", tt.note, got.Items, tt.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	outboxFile        = "outbox.json"
+	outboxSendTimeout = 10 * time.Second
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = 15 * time.Second
+	outboxMaxBackoff  = time.Hour
+	outboxKeepSettled = 7 * 24 * time.Hour
+	outboxMaxSettled  = 200
+	deliveryPending   = "pending"
+	deliveryDelivered = "ok"
+	deliveryFailed    = "failed"
+)
+
+// delivery tracks one event on one relay.
+type delivery struct {
+	Status   string    `json:"status"`
+	Attempts int       `json:"attempts"`
+	NextTry  time.Time `json:"next_try,omitempty"`
+	Message  string    `json:"message,omitempty"` // relay OK message or last error
+	Updated  time.Time `json:"updated"`
+}
+
+// outboxEntry is a signed event and its delivery state per write relay.
+type outboxEntry struct {
+	Event   nostr.Event          `json:"event"`
+	Relays  map[string]*delivery `json:"relays"`
+	Created time.Time            `json:"created"`
+}
+
+// deliveryStatus reports the outcome of one delivery attempt.
+type deliveryStatus struct {
+	Relay   string
+	Status  nostr.Status
+	Message string
+	Retry   bool // a failed attempt that will be retried later
+}
+
+// outboxMu serializes read-modify-write cycles on the outbox file between
+// concurrent deliveries. Use lockOutbox, which also keeps other processes out.
+var outboxMu sync.Mutex
+
+// lockOutbox takes outboxMu and a lock on outbox.json.lock, so that the
+// daemon, the TUI and one-off commands sharing a datadir don't write over
+// each other's changes. Call the returned function to release both.
+func lockOutbox() (unlock func()) {
+	return lockDataFile(outboxFile, &outboxMu)
+}
+
+// readOutbox loads the outbox for display.
+func readOutbox() []outboxEntry {
+	unlock := lockOutbox()
+	defer unlock()
+	return loadOutbox()
+}
+
+func loadOutbox() []outboxEntry {
+	var entries []outboxEntry
+	if err := loadDataFile(outboxFile, &entries); err != nil {
+		log.Printf("can't read %s: %s", outboxFile, err)
+	}
+	return entries
+}
+
+func saveOutbox(entries []outboxEntry) {
+	if err := saveDataFile(outboxFile, pruneOutbox(entries)); err != nil {
+		log.Printf("can't write %s: %s", outboxFile, err)
+	}
+}
+
+// settled reports whether no relay is still waiting for the entry.
+func (e outboxEntry) settled() bool {
+	for _, d := range e.Relays {
+		if d.Status == deliveryPending {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneOutbox drops settled entries older than outboxKeepSettled and caps the
+// number of settled entries kept for the publish log.
+func pruneOutbox(entries []outboxEntry) []outboxEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+	out := entries[:0]
+	kept := 0
+	for _, e := range entries {
+		if e.settled() {
+			if time.Since(e.Created) > outboxKeepSettled || kept >= outboxMaxSettled {
+				continue
+			}
+			kept++
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// updateDelivery applies fn to the delivery of event id on relay and saves the outbox.
+func updateDelivery(id, relay string, fn func(d *delivery)) {
+	unlock := lockOutbox()
+	defer unlock()
+	entries := loadOutbox()
+	for _, e := range entries {
+		if e.Event.ID != id {
+			continue
+		}
+		if d, ok := e.Relays[relay]; ok {
+			fn(d)
+			d.Updated = time.Now()
+		}
+	}
+	saveOutbox(entries)
+}
+
+// permanentRejection reports whether a NIP-20 OK message means resending the
+// same event can't succeed.
+func permanentRejection(message string) bool {
+	for _, prefix := range []string{"blocked:", "invalid:", "pow:", "restricted:"} {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry attempt.
+func backoff(attempts int) time.Duration {
+	wait := outboxBaseBackoff
+	for i := 1; i < attempts && wait < outboxMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > outboxMaxBackoff {
+		wait = outboxMaxBackoff
+	}
+	return wait
+}
+
+// deliver sends ev to relay once, records the result in the outbox and
+// reports it on statuses.
+func deliver(ev nostr.Event, relay string, statuses chan<- deliveryStatus) {
+	accepted, message, err := sendEventTo(relay, ev, outboxSendTimeout, func() {
+		statuses <- deliveryStatus{Relay: relay, Status: nostr.PublishStatusSent}
+	})
+	var status deliveryStatus
+	updateDelivery(ev.ID, relay, func(d *delivery) {
+		d.Attempts++
+		switch {
+		case err == nil && (accepted || strings.HasPrefix(message, "duplicate:")):
+			d.Status = deliveryDelivered
+			d.Message = message
+			status = deliveryStatus{Relay: relay, Status: nostr.PublishStatusSucceeded, Message: message}
+			return
+		case err == nil:
+			d.Message = message
+		default:
+			d.Message = err.Error()
+		}
+		if (err == nil && permanentRejection(message)) || d.Attempts >= outboxMaxAttempts {
+			d.Status = deliveryFailed
+		} else {
+			d.NextTry = time.Now().Add(backoff(d.Attempts))
+		}
+		status = deliveryStatus{Relay: relay, Status: nostr.PublishStatusFailed, Message: d.Message,
+			Retry: d.Status == deliveryPending}
+	})
+	statuses <- status
+}
+
+// deliverAll attempts the given relays in parallel and closes the returned
+// channel once every attempt finished.
+func deliverAll(ev nostr.Event, relays []string) chan deliveryStatus {
+	statuses := make(chan deliveryStatus, 2*len(relays))
+	var wg sync.WaitGroup
+	for _, relay := range relays {
+		wg.Add(1)
+		go func(relay string) {
+			defer wg.Done()
+			deliver(ev, relay, statuses)
+		}(relay)
+	}
+	go func() {
+		wg.Wait()
+		close(statuses)
+	}()
+	return statuses
+}
+
+// writeRelays returns the configured relays we publish to.
+func writeRelays() []string {
+	var relays []string
+	for url, policy := range config.Relays {
+		if policy.Write {
+			relays = append(relays, nostr.NormalizeURL(url))
+		}
+	}
+	sort.Strings(relays)
+	return relays
+}
+
+// publishEvent signs ev if needed, stores it in the outbox and starts
+// delivering it to every write relay. The returned channel reports each
+// relay's outcome and is closed when all first attempts are done; failed
+// deliveries stay in the outbox and are retried by flushOutbox.
+func publishEvent(ev *nostr.Event) (*nostr.Event, chan deliveryStatus, error) {
+	if ev.Sig == "" {
+		if err := signEvent(ev); err != nil {
+			return nil, nil, err
+		}
+	}
+	relays := writeRelays()
+	if len(relays) == 0 {
+		return nil, nil, errors.New("no write relays configured")
+	}
+
+	entry := outboxEntry{Event: *ev, Relays: make(map[string]*delivery), Created: time.Now()}
+	for _, relay := range relays {
+		// hold off flushOutbox until the first attempt below has finished
+		entry.Relays[relay] = &delivery{Status: deliveryPending, NextTry: time.Now().Add(2 * outboxSendTimeout), Updated: time.Now()}
+	}
+	unlock := lockOutbox()
+	saveOutbox(append(loadOutbox(), entry))
+	unlock()
+
+	return ev, deliverAll(*ev, relays), nil
+}
+
+// flushOutbox retries every pending delivery that is due (all of them when
+// force is set, including failed ones) and waits for the results.
+func flushOutbox(force bool) (delivered, failed int) {
+	unlock := lockOutbox()
+	entries := loadOutbox()
+	type job struct {
+		ev     nostr.Event
+		relays []string
+	}
+	var jobs []job
+	now := time.Now()
+	for _, e := range entries {
+		var relays []string
+		for relay, d := range e.Relays {
+			if force && d.Status == deliveryFailed {
+				d.Status = deliveryPending
+				d.Attempts = 0
+			}
+			if d.Status == deliveryPending && (force || !d.NextTry.After(now)) {
+				relays = append(relays, relay)
+			}
+		}
+		if len(relays) > 0 {
+			jobs = append(jobs, job{e.Event, relays})
+		}
+	}
+	if force {
+		saveOutbox(entries)
+	}
+	unlock()
+
+	for _, j := range jobs {
+		for status := range deliverAll(j.ev, j.relays) {
+			switch status.Status {
+			case nostr.PublishStatusSucceeded:
+				delivered++
+			case nostr.PublishStatusFailed:
+				failed++
+			}
+		}
+	}
+	return delivered, failed
+}
+
+// outboxCounts returns the number of deliveries still pending and given up on.
+func outboxCounts() (pending, failed int) {
+	for _, e := range readOutbox() {
+		for _, d := range e.Relays {
+			switch d.Status {
+			case deliveryPending:
+				pending++
+			case deliveryFailed:
+				failed++
+			}
+		}
+	}
+	return pending, failed
+}
+
+func outbox(opts docopt.Opts) {
+	switch {
+	case opts["retry"].(bool):
+		delivered, failed := flushOutbox(true)
+		fmt.Printf("Delivered %d, failed %d.\n", delivered, failed)
+		return
+	case opts["clear"].(bool):
+		unlock := lockOutbox()
+		var keep []outboxEntry
+		for _, e := range loadOutbox() {
+			if !e.settled() {
+				keep = append(keep, e)
+			}
+		}
+		saveOutbox(keep)
+		unlock()
+		fmt.Println("Cleared settled events from the outbox.")
+		return
+	}
+
+	shown := 0
+	for _, e := range readOutbox() {
+		all, _ := opts.Bool("--all")
+		if e.settled() && !all {
+			hasFailed := false
+			for _, d := range e.Relays {
+				hasFailed = hasFailed || d.Status == deliveryFailed
+			}
+			if !hasFailed {
+				continue
+			}
+		}
+		shown++
+		kind, ok := kindNames[e.Event.Kind]
+		if !ok {
+			kind = fmt.Sprintf("Kind %d", e.Event.Kind)
+		}
+		fmt.Printf("%s [%s] %s\n", kind, shorten(e.Event.ID), humanize.Time(e.Created))
+		relays := make([]string, 0, len(e.Relays))
+		for relay := range e.Relays {
+			relays = append(relays, relay)
+		}
+		sort.Strings(relays)
+		for _, relay := range relays {
+			d := e.Relays[relay]
+			line := fmt.Sprintf("  %-8s %s", d.Status, relay)
+			if d.Status == deliveryPending && d.Attempts > 0 {
+				line += fmt.Sprintf(" (attempt %d, next %s)", d.Attempts, humanize.Time(d.NextTry))
+			}
+			if d.Message != "" {
+				line += ": " + d.Message
+			}
+			fmt.Println(line)
+		}
+	}
+	if shown == 0 {
+		fmt.Println("Outbox is empty, everything was delivered.")
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const KindFollowSet = 30000 // NIP-51 people lists, one per "d" tag
+
+const peopleListsFile = "lists.json"
+
+// followSet returns the spec of the people list called name. Its title,
+// description and image tags end up in nip51List.Other and are published
+// again unchanged.
+func followSet(name string) listSpec {
+	return listSpec{Kind: KindFollowSet, D: name, Types: []string{"p"}}
+}
+
+// our people lists by name, as read from the datadir
+var peopleListCache struct {
+	sync.Mutex
+	dir   string
+	lists map[string]nip51List
+}
+
+func loadPeopleLists() map[string]nip51List {
+	peopleListCache.Lock()
+	defer peopleListCache.Unlock()
+	if peopleListCache.lists == nil || peopleListCache.dir != config.DataDir {
+		lists := make(map[string]nip51List)
+		if err := loadDataFile(peopleListsFile, &lists); err != nil {
+			log.Printf("Can't read %s: %s.\n", peopleListsFile, err)
+		}
+		peopleListCache.dir = config.DataDir
+		peopleListCache.lists = lists
+	}
+	lists := make(map[string]nip51List, len(peopleListCache.lists))
+	for name, list := range peopleListCache.lists {
+		lists[name] = list
+	}
+	return lists
+}
+
+func savePeopleLists(lists map[string]nip51List) {
+	if err := saveDataFile(peopleListsFile, lists); err != nil {
+		log.Printf("Can't write %s: %s.\n", peopleListsFile, err)
+	}
+	peopleListCache.Lock()
+	peopleListCache.dir = config.DataDir
+	peopleListCache.lists = lists
+	peopleListCache.Unlock()
+}
+
+// peopleListNames returns the names of lists, sorted.
+func peopleListNames(lists map[string]nip51List) []string {
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listTagValue returns the value of the first public tag called name that
+// list keeps as it is, such as a follow set's "title".
+func listTagValue(list nip51List, name string) string {
+	for _, tag := range list.Other {
+		if !tag.Private && len(tag.Tag) >= 2 && tag.Tag[0] == name {
+			return tag.Tag[1]
+		}
+	}
+	return ""
+}
+
+// listMembers returns the pubkeys on list.
+func listMembers(list nip51List) []string {
+	var keys []string
+	for _, item := range list.Items {
+		if item.Type == "p" {
+			keys = append(keys, item.Value)
+		}
+	}
+	return keys
+}
+
+// listsWith returns the names of our lists pubkey is on.
+func listsWith(pubkey string) []string {
+	lists := loadPeopleLists()
+	var names []string
+	for _, name := range peopleListNames(lists) {
+		for _, key := range listMembers(lists[name]) {
+			if key == pubkey {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func validListName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n,") {
+		return errors.New("list names can't be empty or contain spaces or commas")
+	}
+	return nil
+}
+
+// fetchFollowSets returns the newest people list of pubkey per name. It
+// fails with errNoReply when no relay replied. The pool must be initialized.
+func fetchFollowSets(pubkey string) (map[string]nostr.Event, error) {
+	newest := make(map[string]nostr.Event)
+	sub := subscribePool(nostr.Filters{{Authors: []string{pubkey}, Kinds: []int{KindFollowSet}}})
+	defer sub.Close()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+		if ev.Kind != KindFollowSet || ev.PubKey != pubkey {
+			continue
+		}
+		name := ""
+		if d := ev.Tags.GetFirst([]string{"d", ""}); d != nil {
+			name = d.Value()
+		}
+		if prev, ok := newest[name]; !ok || ev.CreatedAt.After(prev.CreatedAt) {
+			newest[name] = ev
+		}
+	}
+	if !sub.answered() {
+		return newest, errNoReply
+	}
+	return newest, nil
+}
+
+// syncPeopleLists picks up our lists from the relays that are newer than
+// the cached ones. Lists emptied elsewhere are dropped.
+// The pool must be initialized.
+func syncPeopleLists() (map[string]nip51List, error) {
+	listOps.Lock()
+	defer listOps.Unlock()
+	return syncPeopleListsLocked()
+}
+
+func syncPeopleListsLocked() (map[string]nip51List, error) {
+	lists := loadPeopleLists()
+	if config.PrivateKey == "" {
+		return lists, nil
+	}
+	sets, err := fetchFollowSets(getPubKey(config.PrivateKey))
+	if err != nil {
+		// publishing now could replace lists we haven't seen
+		return lists, err
+	}
+	var errs []string
+	changed := false
+	for name, ev := range sets {
+		ev := ev
+		if local, ok := lists[name]; ok && !ev.CreatedAt.After(local.CreatedAt) {
+			continue
+		}
+		remote, err := followSet(name).parse(&ev)
+		if err != nil {
+			errs = append(errs, name+": "+err.Error())
+			continue
+		}
+		if len(remote.Items) > 0 {
+			lists[name] = remote
+			changed = true
+		} else if _, ok := lists[name]; ok {
+			delete(lists, name)
+			changed = true
+		}
+	}
+	if changed {
+		savePeopleLists(lists)
+	}
+	if len(errs) > 0 {
+		return lists, errors.New(strings.Join(errs, "; "))
+	}
+	return lists, nil
+}
+
+// updatePeopleList adds items to or removes them from the list called name,
+// creating it when needed, and publishes it. The pool must be initialized.
+func updatePeopleList(name string, items []listItem, add bool) (*nostr.Event, chan deliveryStatus, error) {
+	if config.PrivateKey == "" {
+		return nil, nil, errors.New("no private key set")
+	}
+	if err := validListName(name); err != nil {
+		return nil, nil, err
+	}
+	listOps.Lock()
+	defer listOps.Unlock()
+	lists, err := syncPeopleListsLocked()
+	if err != nil {
+		return nil, nil, err
+	}
+	list, ok := lists[name]
+	if !ok && !add {
+		return nil, nil, fmt.Errorf("no list called %s", name)
+	}
+	spec := followSet(name)
+	if list, err = spec.apply(list, items, add); err != nil {
+		return nil, nil, err
+	}
+	event, statuses, err := spec.publish(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	list.CreatedAt = event.CreatedAt
+	if len(list.Items) == 0 {
+		delete(lists, name)
+	} else {
+		lists[name] = list
+	}
+	savePeopleLists(lists)
+	return event, statuses, nil
+}
+
+// deletePeopleList empties the list called name on the relays and asks
+// them to delete it (NIP-09). The pool must be initialized.
+func deletePeopleList(name string) (*nostr.Event, chan deliveryStatus, error) {
+	if config.PrivateKey == "" {
+		return nil, nil, errors.New("no private key set")
+	}
+	listOps.Lock()
+	defer listOps.Unlock()
+	lists, err := syncPeopleListsLocked()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := lists[name]; !ok {
+		return nil, nil, fmt.Errorf("no list called %s", name)
+	}
+	// relays that ignore the deletion still replace the list
+	event, statuses, err := followSet(name).publish(nip51List{})
+	if err != nil {
+		return nil, nil, err
+	}
+	address := fmt.Sprintf("%d:%s:%s", KindFollowSet, event.PubKey, name)
+	if _, _, err := publishEvent(&nostr.Event{
+		CreatedAt: time.Now(),
+		Kind:      nostr.KindDeletion,
+		Tags:      nostr.Tags{nostr.Tag{"a", address}},
+	}); err != nil {
+		log.Printf("Can't publish the deletion of %s: %s.\n", name, err)
+	}
+	delete(lists, name)
+	savePeopleLists(lists)
+	return event, statuses, nil
+}
+
+// importPeopleList adds the public members of the list called name by
+// pubkey to our list called as; people already on it keep their entry.
+// The pool must be initialized.
+func importPeopleList(pubkey, name, as string) (int, *nostr.Event, chan deliveryStatus, error) {
+	sets, err := fetchFollowSets(pubkey)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	ev, ok := sets[name]
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("%s has no list called %s", shorten(pubkey), name)
+	}
+	remote, _ := followSet(name).parse(&ev)
+	ours, err := syncPeopleLists()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	have := make(map[string]bool)
+	for _, key := range listMembers(ours[as]) {
+		have[key] = true
+	}
+	var items []listItem
+	for _, key := range listMembers(remote) {
+		if !have[key] {
+			items = append(items, listItem{Type: "p", Value: key})
+		}
+	}
+	if len(items) == 0 {
+		return 0, nil, nil, fmt.Errorf("nobody new on %s", name)
+	}
+	event, statuses, err := updatePeopleList(as, items, true)
+	return len(items), event, statuses, err
+}
+
+func peopleLists(opts docopt.Opts) {
+	initNostr()
+
+	switch {
+	case opts["add"].(bool), opts["remove"].(bool):
+		name := opts["<name>"].(string)
+		private, _ := opts.Bool("--private")
+		var items []listItem
+		for _, arg := range opts["<member>"].([]string) {
+			key, err := pubkeyArg(arg)
+			if err != nil {
+				log.Printf("%s: %s.\n", arg, err)
+				return
+			}
+			items = append(items, listItem{Type: "p", Value: key, Private: private})
+		}
+		add := opts["add"].(bool)
+		event, statuses, err := updatePeopleList(name, items, add)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if add {
+			fmt.Printf("Added %d to %s.\n", len(items), name)
+		} else {
+			fmt.Printf("Removed %d from %s.\n", len(items), name)
+		}
+		printPublishStatus(event, statuses)
+	case opts["delete"].(bool):
+		name := opts["<name>"].(string)
+		event, statuses, err := deletePeopleList(name)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		fmt.Printf("Deleted %s.\n", name)
+		printPublishStatus(event, statuses)
+	case opts["import"].(bool):
+		pubkey, err := pubkeyArg(opts["<pubkey>"].(string))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		name := opts["<name>"].(string)
+		as, _ := opts.String("--as")
+		if as == "" {
+			as = name
+		}
+		n, event, statuses, err := importPeopleList(pubkey, name, as)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		fmt.Printf("Imported %d people into %s.\n", n, as)
+		printPublishStatus(event, statuses)
+	case opts["show"].(bool):
+		lists, err := syncPeopleLists()
+		if err != nil {
+			log.Println(err)
+		}
+		name := opts["<name>"].(string)
+		list, ok := lists[name]
+		if !ok {
+			log.Printf("No list called %s.\n", name)
+			return
+		}
+		if title := listTagValue(list, "title"); title != "" {
+			fmt.Println(title)
+		}
+		if description := listTagValue(list, "description"); description != "" {
+			fmt.Println(description)
+		}
+		for _, item := range list.Items {
+			if item.Private {
+				fmt.Println(item, "(private)")
+			} else {
+				fmt.Println(item)
+			}
+		}
+	default:
+		if arg, ok := opts["<pubkey>"].(string); ok && arg != "" {
+			showFollowSets(arg)
+			return
+		}
+		lists, err := syncPeopleLists()
+		if err != nil {
+			log.Println(err)
+		}
+		if len(lists) == 0 {
+			fmt.Println("No people lists.")
+			return
+		}
+		for _, name := range peopleListNames(lists) {
+			printListName(name, lists[name])
+		}
+	}
+}
+
+// showFollowSets prints the people lists someone published, for import.
+func showFollowSets(arg string) {
+	pubkey, err := pubkeyArg(arg)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	sets, err := fetchFollowSets(pubkey)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(sets) == 0 {
+		fmt.Println("No people lists found.")
+		return
+	}
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ev := sets[name]
+		list, _ := followSet(name).parse(&ev)
+		if len(list.Items) == 0 {
+			continue
+		}
+		printListName(name, list)
+	}
+}
+
+// printListName prints a people list's name, size and title.
+func printListName(name string, list nip51List) {
+	if title := listTagValue(list, "title"); title != "" && title != name {
+		fmt.Printf("%s (%d): %s\n", name, len(listMembers(list)), title)
+	} else {
+		fmt.Printf("%s (%d)\n", name, len(listMembers(list)))
+	}
+}
@@ -67,10 +67,11 @@ func printEvent(evt nostr.Event, nick *string, verbose bool, jsonformat bool) {
 		}
 	}
 
-	fmt.Printf("%s [%s] from %s %s\n",
+	fmt.Printf("%s [%s] from %s%s %s\n",
 		kind,
 		ID,
 		fromField,
+		nip05Mark(evt.PubKey),
 		humanize.Time(evt.CreatedAt),
 	)
 
@@ -90,6 +91,13 @@ func printEvent(evt nostr.Event, nick *string, verbose bool, jsonformat bool) {
 		}
 		str := strings.Join(spl, "\n")
 		fmt.Print(str)
+		if metadata.NIP05 != "" {
+			if checkNIP05(metadata.NIP05, evt.PubKey) {
+				fmt.Print("nip05 verified ✓")
+			} else {
+				fmt.Print("nip05 not verified")
+			}
+		}
 	case nostr.KindTextNote:
 		fmt.Print("  " + strings.ReplaceAll(evt.Content, "\n", "\n  "))
 	case nostr.KindBoost:
@@ -144,15 +152,20 @@ func shorten(id string) string {
 	return id[0:4] + "..." + id[len(id)-4:]
 }
 
-func printPublishStatus(event *nostr.Event, statuses chan nostr.PublishStatus) {
+func printPublishStatus(event *nostr.Event, statuses chan deliveryStatus) {
+	retrying := false
 	for status := range statuses {
 		switch status.Status {
 		case nostr.PublishStatusSent:
 			fmt.Printf("Sent event %s to '%s'.\n", event.ID, status.Relay)
 		case nostr.PublishStatusFailed:
-			fmt.Printf("Failed to send event %s to '%s'.\n", event.ID, status.Relay)
+			fmt.Printf("Failed to send event %s to '%s': %s.\n", event.ID, status.Relay, status.Message)
+			retrying = retrying || status.Retry
 		case nostr.PublishStatusSucceeded:
 			fmt.Printf("Seen %s on '%s'.\n", event.ID, status.Relay)
 		}
 	}
+	if retrying {
+		fmt.Println("Kept in the outbox; run 'noscl outbox retry' or 'noscl daemon' to retry.")
+	}
 }
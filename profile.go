@@ -19,28 +19,45 @@ func showProfile(opts docopt.Opts) {
 	}
 
 	initNostr()
+	addAuthorRelays([]string{key})
 
-	_, all := pool.Sub(nostr.Filters{{Authors: []string{key}, Kinds: []int{0}}})
-	for event := range nostr.Unique(all) {
+	sub := subscribePool(nostr.Filters{{Authors: []string{key}, Kinds: []int{0}}})
+	defer sub.Close()
+	for event := range nostr.Unique(sub.Events) {
 		printEvent(event, nil, verbose, jsonformat)
 	}
 }
 
 func follow(opts docopt.Opts) {
-	key := nip19.TranslatePublicKey(opts["<pubkey>"].(string))
-	if key == "" {
-		log.Println("Follow key is empty! Exiting.")
-		return
-	}
-
 	name, err := opts.String("--name")
 	if err != nil {
 		name = ""
 	}
 
+	// name@domain is resolved through NIP-05, which also gives relay hints
+	var relays []string
+	arg := opts["<pubkey>"].(string)
+	key := nip19.TranslatePublicKey(arg)
+	if looksLikeNIP05(arg) {
+		entry, err := resolveNIP05(arg)
+		if err != nil {
+			log.Printf("Can't resolve %s: %s.\n", arg, err)
+			return
+		}
+		key, relays = entry.Pubkey, entry.Relays
+		if local, _, _ := splitNIP05(arg); name == "" && local != "_" {
+			name = local
+		}
+	}
+	if key == "" {
+		log.Println("Follow key is empty! Exiting.")
+		return
+	}
+
         config.Following[key] = Follow{
-		Key:  key,
-		Name: name,
+		Key:    key,
+		Name:   name,
+		Relays: relays,
 	}
 	fmt.Printf("Followed %s.\n", key)
 }
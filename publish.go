@@ -1,14 +1,17 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"github.com/docopt/docopt-go"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip10"
 )
 
 func publish(opts docopt.Opts) {
@@ -17,8 +20,6 @@ func publish(opts docopt.Opts) {
 		return
 	}
 
-	initNostr()
-
 	var event nostr.Event
 
 	if file, _ := opts.String("--file"); file != "" {
@@ -51,43 +52,58 @@ func publish(opts docopt.Opts) {
 			tags = append(tags, nostr.Tag{"p", profile})
 		}
 
-		content, _ := opts.String("<content>")
+		content, err := readContentOpt(opts, "Write your note above the line.")
+		if err != nil {
+			log.Printf("Failed reading content: %s", err)
+			return
+		}
 		if content == "" {
 			log.Printf("Content must not be empty")
 			return
 		}
-		if content == "-" {
-			content, err = readContentStdin(4096)
-			if err != nil {
-				log.Printf("Failed reading content from stdin: %s", err)
-				return
-			}
-		}
 
 		event = nostr.Event{
 			CreatedAt: time.Now(),
 			Kind:      nostr.KindTextNote,
-			Tags:      tags,
+			Tags:      contentTags(tags, content),
 			Content:   content,
 		}
 	}
 
-	publishEvent, statuses, err := pool.PublishEvent(&event)
+	if at, _ := opts.String("--at"); at != "" {
+		when, err := parseScheduleTime(at)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		scheduled, err := schedulePost(event, when)
+		if err != nil {
+			log.Printf("Failed to schedule: %s.\n", err)
+			return
+		}
+		fmt.Printf("Scheduled %s for %s. Run 'noscl daemon' or the TUI to send it.\n",
+			scheduled.ID, when.Format("2006-01-02 15:04"))
+		return
+	}
+
+	published, statuses, err := publishEvent(&event)
 	if err != nil {
 		log.Printf("Error publishing: %s.\n", err.Error())
 		return
 	}
+	if published.Kind == nostr.KindTextNote {
+		rememberHashtags(published.Content)
+	}
 
-	printPublishStatus(publishEvent, statuses)
+	printPublishStatus(published, statuses)
 }
 
 // PublishReaction sends a kind-7 reaction (like) to the given event. NIP-25.
-// Returns the created event ID on success.
-func PublishReaction(evID, authorPubkey string) (string, error) {
+// Returns the created event and its per-relay delivery statuses.
+func PublishReaction(evID, authorPubkey string) (*nostr.Event, chan deliveryStatus, error) {
 	if config.PrivateKey == "" {
-		return "", errors.New("private key not set")
+		return nil, nil, errors.New("private key not set")
 	}
-	initNostr()
 	tags := nostr.Tags{
 		{"e", evID},
 		{"p", authorPubkey},
@@ -98,21 +114,16 @@ func PublishReaction(evID, authorPubkey string) (string, error) {
 		Tags:      tags,
 		Content:   "+",
 	}
-	pub, _, err := pool.PublishEvent(&ev)
-	if err != nil {
-		return "", err
-	}
-	return pub.ID, nil
+	return publishEvent(&ev)
 }
 
 // PublishBoost sends a kind-6 boost (repost) for the given event. NIP-18.
 // eventJSON is the stringified JSON of the boosted event (recommended by NIP-18).
-// Returns the created event ID on success.
-func PublishBoost(evID, authorPubkey, eventJSON string) (string, error) {
+// Returns the created event and its per-relay delivery statuses.
+func PublishBoost(evID, authorPubkey, eventJSON string) (*nostr.Event, chan deliveryStatus, error) {
 	if config.PrivateKey == "" {
-		return "", errors.New("private key not set")
+		return nil, nil, errors.New("private key not set")
 	}
-	initNostr()
 	tags := nostr.Tags{
 		{"e", evID},
 		{"p", authorPubkey},
@@ -123,51 +134,139 @@ func PublishBoost(evID, authorPubkey, eventJSON string) (string, error) {
 		Tags:      tags,
 		Content:   eventJSON,
 	}
-	pub, _, err := pool.PublishEvent(&ev)
+	return publishEvent(&ev)
+}
+
+// readContentOpt returns the <content> argument, reading it from stdin when it
+// is '-' and from the user's editor when --edit is given. help is shown below
+// the draft in the editor.
+func readContentOpt(opts docopt.Opts, help ...string) (string, error) {
+	content, _ := opts.String("<content>")
+	if content == "-" {
+		var err error
+		content, err = readContentStdin(maxContentBytes)
+		if err != nil {
+			return "", err
+		}
+	}
+	if edit, _ := opts.Bool("--edit"); edit {
+		return editContent(content, help...)
+	}
+	return content, nil
+}
+
+func reply(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Printf("Can't reply. Private key not set.\n")
+		return
+	}
+	id := opts["<id>"].(string)
+	if id == "" {
+		log.Println("provided event ID was empty")
+		return
+	}
+
+	initNostr()
+
+	var parent *nostr.Event
+	sub := subscribePool(nostr.Filters{{IDs: []string{id}}})
+	defer sub.Close()
+	for event := range iterEventsWithTimeout(nostr.Unique(sub.Events), 5*time.Second) {
+		if event.ID == id {
+			parent = &event
+			break
+		}
+	}
+	if parent == nil {
+		log.Printf("Event %s not found on any relay.\n", id)
+		return
+	}
+
+	content, err := readContentOpt(opts, "Replying to "+shorten(parent.PubKey)+":", "", parent.Content)
 	if err != nil {
-		return "", err
+		log.Printf("Failed reading content: %s", err)
+		return
+	}
+	if content == "" {
+		log.Printf("Content must not be empty")
+		return
+	}
+
+	rootID, rootAuthor := parent.ID, parent.PubKey
+	if root := nip10.GetThreadRoot(parent.Tags); root != nil {
+		rootID = root.Value()
+		rootAuthor = ""
+		if p := parent.Tags.GetFirst([]string{"p", ""}); p != nil {
+			rootAuthor = p.Value()
+		}
+	}
+
+	event := replyEvent(rootID, rootAuthor, parent.ID, parent.PubKey, content)
+	published, statuses, err := publishEvent(&event)
+	if err != nil {
+		log.Printf("Error publishing: %s.\n", err.Error())
+		return
 	}
-	return pub.ID, nil
+	rememberHashtags(content)
+
+	printPublishStatus(published, statuses)
 }
 
 // PublishReply publishes a kind-1 reply. NIP-10: root e-tag, optional reply e-tag, p-tags.
-func PublishReply(rootID, rootAuthor, replyID, replyAuthor, content string) error {
+func PublishReply(rootID, rootAuthor, replyID, replyAuthor, content string) (*nostr.Event, chan deliveryStatus, error) {
 	if config.PrivateKey == "" {
-		return errors.New("private key not set")
+		return nil, nil, errors.New("private key not set")
 	}
-	initNostr()
+	ev := replyEvent(rootID, rootAuthor, replyID, replyAuthor, content)
+	published, statuses, err := publishEvent(&ev)
+	if err == nil {
+		rememberHashtags(content)
+	}
+	return published, statuses, err
+}
+
+// replyEvent builds an unsigned kind-1 reply with NIP-10 marked e-tags and
+// p-tags for the thread participants and anyone mentioned in content.
+func replyEvent(rootID, rootAuthor, replyID, replyAuthor, content string) nostr.Event {
 	var tags nostr.Tags
 	tags = append(tags, nostr.Tag{"e", rootID, "", "root"})
 	if replyID != "" && replyID != rootID {
 		tags = append(tags, nostr.Tag{"e", replyID, "", "reply"})
 	}
-	tags = append(tags, nostr.Tag{"p", rootAuthor})
+	if rootAuthor != "" {
+		tags = append(tags, nostr.Tag{"p", rootAuthor})
+	}
 	if replyAuthor != "" && replyAuthor != rootAuthor {
 		tags = append(tags, nostr.Tag{"p", replyAuthor})
 	}
-	ev := nostr.Event{
+	return nostr.Event{
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindTextNote,
-		Tags:      tags,
+		Tags:      contentTags(tags, content),
 		Content:   content,
 	}
-	_, _, err := pool.PublishEvent(&ev)
-	return err
 }
 
 // PublishDeletion publishes a kind-5 deletion for the given event ID. NIP-09.
-func PublishDeletion(evID string) error {
+func PublishDeletion(evID string) (*nostr.Event, chan deliveryStatus, error) {
 	if config.PrivateKey == "" {
-		return errors.New("private key not set")
+		return nil, nil, errors.New("private key not set")
 	}
-	initNostr()
-	_, _, err := pool.PublishEvent(&nostr.Event{
+	return publishEvent(&nostr.Event{
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindDeletion,
 		Tags:      nostr.Tags{{"e", evID}},
 		Content:   "",
 	})
-	return err
+}
+
+// signEvent sets our pubkey on ev and signs it with the configured key.
+func signEvent(ev *nostr.Event) error {
+	if config.PrivateKey == "" {
+		return errors.New("private key not set")
+	}
+	ev.PubKey = getPubKey(config.PrivateKey)
+	return ev.Sign(hex.EncodeToString([]byte(config.PrivateKey)))
 }
 
 func optSlice(opts docopt.Opts, key string) ([]string, error) {
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/docopt/docopt-go"
 	"github.com/nbd-wtf/go-nostr"
@@ -34,17 +36,41 @@ func removeRelay(opts docopt.Opts) {
 	}
 }
 
-// removeRelayURL removes a relay from config. Caller must save config. Pool is not updated until next initNostr.
+// removeRelayURL removes a relay from config and disconnects it if the pool
+// is up. Caller must save config.
 func removeRelayURL(addr string) {
 	delete(config.Relays, addr)
+	if pool == nil {
+		return
+	}
+	// RelayPool.Remove forgets the relay without closing it
+	if relay, ok := pool.Relays.Load(nostr.NormalizeURL(addr)); ok {
+		pool.Remove(addr)
+		relay.Close()
+	}
 }
 
+// recommendRelay publishes a kind-2 relay recommendation. NIP-01.
 func recommendRelay(opts docopt.Opts) {
-	addr := opts["<url>"].(string)
-
-	// TODO
+	if config.PrivateKey == "" {
+		log.Printf("Can't publish. Private key not set.\n")
+		return
+	}
+	addr := nostr.NormalizeURL(opts["<url>"].(string))
 
-	fmt.Printf("Published a relay recommendation for %s.", addr)
+	event := nostr.Event{
+		CreatedAt: time.Now(),
+		Kind:      nostr.KindRecommendServer,
+		Tags:      nostr.Tags{},
+		Content:   addr,
+	}
+	published, statuses, err := publishEvent(&event)
+	if err != nil {
+		log.Printf("Error publishing: %s.\n", err.Error())
+		return
+	}
+	fmt.Printf("Publishing a relay recommendation for %s.\n", addr)
+	printPublishStatus(published, statuses)
 }
 
 func listRelays(opts docopt.Opts) {
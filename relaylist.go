@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	KindRelayList      = 10002 // NIP-65
+	relayListsFile     = "relaylists.json"
+	relayListMaxAge    = 24 * time.Hour
+	maxAuthorRelays    = 6
+	authorRelayTimeout = 5 * time.Second
+)
+
+// relayList is someone's NIP-65 relay list as cached in the datadir.
+type relayList struct {
+	Relays    map[string]Policy `json:"relays"`
+	CreatedAt time.Time         `json:"created_at"`
+	Fetched   time.Time         `json:"fetched"`
+}
+
+// relayListTags turns relays into NIP-65 r-tags, leaving out the marker for
+// relays that are both read and written.
+func relayListTags(relays map[string]Policy) nostr.Tags {
+	urls := make([]string, 0, len(relays))
+	for url := range relays {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	var tags nostr.Tags
+	for _, url := range urls {
+		policy := relays[url]
+		switch {
+		case policy.Read && policy.Write:
+			tags = append(tags, nostr.Tag{"r", nostr.NormalizeURL(url)})
+		case policy.Read:
+			tags = append(tags, nostr.Tag{"r", nostr.NormalizeURL(url), "read"})
+		case policy.Write:
+			tags = append(tags, nostr.Tag{"r", nostr.NormalizeURL(url), "write"})
+		}
+	}
+	return tags
+}
+
+// parseRelayList reads the r-tags of a kind-10002 event.
+func parseRelayList(tags nostr.Tags) map[string]Policy {
+	relays := make(map[string]Policy)
+	for _, tag := range tags.GetAll([]string{"r", ""}) {
+		url := nostr.NormalizeURL(tag.Value())
+		if url == "" {
+			continue
+		}
+		policy := relays[url]
+		marker := ""
+		if len(tag) > 2 {
+			marker = tag[2]
+		}
+		switch marker {
+		case "read":
+			policy.Read = true
+		case "write":
+			policy.Write = true
+		default:
+			policy.Read, policy.Write = true, true
+		}
+		relays[url] = policy
+	}
+	return relays
+}
+
+func loadRelayLists() map[string]relayList {
+	lists := make(map[string]relayList)
+	if err := loadDataFile(relayListsFile, &lists); err != nil {
+		log.Printf("can't read %s: %s", relayListsFile, err)
+	}
+	return lists
+}
+
+// fetchRelayLists returns the relay lists of pubkeys, asking the pool for
+// those that aren't cached or are older than relayListMaxAge (all of them when
+// force is set). Authors without a list are left out.
+func fetchRelayLists(pubkeys []string, force bool) map[string]relayList {
+	lists := loadRelayLists()
+	var stale []string
+	for _, pk := range pubkeys {
+		if l, ok := lists[pk]; force || !ok || time.Since(l.Fetched) > relayListMaxAge {
+			stale = append(stale, pk)
+		}
+	}
+	if len(stale) > 0 && pool != nil {
+		now := time.Now()
+		sub := subscribePool(nostr.Filters{{Authors: stale, Kinds: []int{KindRelayList}}})
+		defer sub.Close()
+		for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+			if ev.Kind != KindRelayList {
+				continue
+			}
+			if l, ok := lists[ev.PubKey]; ok && !ev.CreatedAt.After(l.CreatedAt) {
+				continue
+			}
+			lists[ev.PubKey] = relayList{Relays: parseRelayList(ev.Tags), CreatedAt: ev.CreatedAt, Fetched: now}
+		}
+		// remember that we looked, so authors without a list aren't asked again right away
+		for _, pk := range stale {
+			if l, ok := lists[pk]; !ok {
+				lists[pk] = relayList{Fetched: now}
+			} else if l.Fetched.Before(now) {
+				l.Fetched = now
+				lists[pk] = l
+			}
+		}
+		if err := saveDataFile(relayListsFile, lists); err != nil {
+			log.Printf("can't write %s: %s", relayListsFile, err)
+		}
+	}
+
+	found := make(map[string]relayList)
+	for _, pk := range pubkeys {
+		if l, ok := lists[pk]; ok && len(l.Relays) > 0 {
+			found[pk] = l
+		}
+	}
+	return found
+}
+
+// authorWriteRelays returns up to limit relays the given authors publish to
+// that aren't configured locally, the ones shared by most authors first.
+func authorWriteRelays(pubkeys []string, limit int) []string {
+	counts := make(map[string]int)
+	for _, l := range fetchRelayLists(pubkeys, false) {
+		for url, policy := range l.Relays {
+			if !policy.Write {
+				continue
+			}
+			if _, ok := config.Relays[url]; ok {
+				continue
+			}
+			counts[url]++
+		}
+	}
+	urls := make([]string, 0, len(counts))
+	for url := range counts {
+		urls = append(urls, url)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if counts[urls[i]] != counts[urls[j]] {
+			return counts[urls[i]] > counts[urls[j]]
+		}
+		return urls[i] < urls[j]
+	})
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls
+}
+
+// addAuthorRelays connects the pool, read-only, to the write relays of the
+// given authors so that their content is found even on relays we don't use.
+func addAuthorRelays(pubkeys []string) {
+	if pool == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, url := range authorWriteRelays(pubkeys, maxAuthorRelays) {
+		if _, ok := pool.Relays.Load(url); ok {
+			continue
+		}
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), authorRelayTimeout)
+			defer cancel()
+			if err := pool.AddContext(ctx, url, nostr.SimplePolicy{Read: true, Write: false}); err != nil {
+				log.Printf("error adding relay '%s': %s", url, err.Error())
+			}
+		}(url)
+	}
+	wg.Wait()
+}
+
+func publishRelayList(opts docopt.Opts) {
+	if config.PrivateKey == "" {
+		log.Printf("Can't publish. Private key not set.\n")
+		return
+	}
+	if len(config.Relays) == 0 {
+		log.Println("No relays configured.")
+		return
+	}
+
+	event := nostr.Event{
+		CreatedAt: time.Now(),
+		Kind:      KindRelayList,
+		Tags:      relayListTags(config.Relays),
+		Content:   "",
+	}
+	published, statuses, err := publishEvent(&event)
+	if err != nil {
+		log.Printf("Error publishing: %s.\n", err.Error())
+		return
+	}
+	printPublishStatus(published, statuses)
+}
+
+func importRelayList(opts docopt.Opts) {
+	key := nip19.TranslatePublicKey(opts["<pubkey>"].(string))
+	if key == "" {
+		log.Println("Invalid pubkey.")
+		return
+	}
+
+	initNostr()
+
+	list, ok := fetchRelayLists([]string{key}, true)[key]
+	if !ok {
+		log.Printf("No relay list (kind %d) found for %s.\n", KindRelayList, key)
+		return
+	}
+	if replace, _ := opts.Bool("--replace"); replace {
+		config.Relays = make(map[string]Policy)
+	}
+	for url, policy := range list.Relays {
+		config.Relays[url] = policy
+		fmt.Printf("%s: %s\n", url, policy)
+	}
+	fmt.Printf("Imported %d relays from %s.\n", len(list.Relays), key)
+}
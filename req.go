@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const reqTimeout = 10 * time.Second
+
+// reqFilters reads the filters given as arguments, each a JSON object or an
+// array of them; "-" reads them from stdin. They are kept as written so that
+// any field a relay understands gets through.
+func reqFilters(args []string) ([]json.RawMessage, error) {
+	var filters []json.RawMessage
+	for _, arg := range args {
+		var r io.Reader = strings.NewReader(arg)
+		if arg == "-" {
+			r = os.Stdin
+		}
+		dec := json.NewDecoder(r)
+		for {
+			var v json.RawMessage
+			if err := dec.Decode(&v); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid filter: %w", err)
+			}
+			var list []json.RawMessage
+			if json.Unmarshal(v, &list) != nil {
+				list = []json.RawMessage{v}
+			}
+			for _, f := range list {
+				var obj map[string]json.RawMessage
+				if err := json.Unmarshal(f, &obj); err != nil {
+					return nil, fmt.Errorf("filter %s is not an object", f)
+				}
+				filters = append(filters, f)
+			}
+		}
+	}
+	return filters, nil
+}
+
+func req(opts docopt.Opts) {
+	filters, err := reqFilters(opts["<filter>"].([]string))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(filters) == 0 {
+		log.Println("No filter given.")
+		return
+	}
+	urls, _ := optSlice(opts, "--relay")
+	if len(urls) == 0 {
+		for url, policy := range config.Relays {
+			if policy.Read {
+				urls = append(urls, url)
+			}
+		}
+		sort.Strings(urls)
+	}
+	if len(urls) == 0 {
+		log.Println("No read relays configured.")
+		return
+	}
+	keepOpen, _ := opts.Bool("--stream")
+	timeout := reqTimeout
+	if secs, err := opts.Int("--timeout"); err == nil && secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	events := make(chan nostr.Event)
+	var wg sync.WaitGroup
+	for _, url := range urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			reqRelay(ctx, url, filters, timeout, keepOpen, events)
+		}(url)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	// one event per line (NDJSON), each once whichever relays sent it
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	seen := make(map[string]bool)
+	for ev := range events {
+		if !seen[ev.ID] {
+			seen[ev.ID] = true
+			enc.Encode(ev)
+		}
+	}
+}
+
+// reqRelay sends filters to the relay at url and passes on the events it
+// returns, reporting on stderr how the subscription went. It returns after
+// EOSE unless keepOpen, when the relay closes the subscription, or when it
+// stays silent for timeout before EOSE.
+func reqRelay(ctx context.Context, url string, filters []json.RawMessage, timeout time.Duration, keepOpen bool, events chan<- nostr.Event) {
+	report := func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", url, fmt.Sprintf(format, args...))
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	w, err := dialRelay(dialCtx, url)
+	cancel()
+	if err != nil {
+		report("%s", err)
+		return
+	}
+	defer w.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// ends the read below
+			w.Close()
+		case <-done:
+		}
+	}()
+
+	id := subscriptionID("req")
+	msg := []interface{}{"REQ", id}
+	for _, f := range filters {
+		msg = append(msg, f)
+	}
+	if err := w.write(msg...); err != nil {
+		report("%s", err)
+		return
+	}
+	defer w.write("CLOSE", id)
+
+	count := 0
+	eose := false
+	for {
+		if eose {
+			w.conn.SetReadDeadline(time.Time{})
+		} else {
+			w.conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+		label, args, err := w.read()
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case ctx.Err() != nil:
+				report("interrupted after %d events", count)
+			case errors.As(err, &netErr) && netErr.Timeout():
+				report("no EOSE within %s, %d events", timeout, count)
+			default:
+				report("%s after %d events", err, count)
+			}
+			return
+		}
+		var sub string
+		if len(args) > 0 {
+			json.Unmarshal(args[0], &sub)
+		}
+		switch label {
+		case "EVENT":
+			if sub != id || len(args) < 2 {
+				continue
+			}
+			var ev nostr.Event
+			if err := json.Unmarshal(args[1], &ev); err != nil {
+				report("unreadable event: %s", err)
+				continue
+			}
+			if ok, _ := ev.CheckSignature(); !ok {
+				report("invalid signature on %s", ev.ID)
+				continue
+			}
+			count++
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				report("interrupted after %d events", count)
+				return
+			}
+		case "EOSE":
+			if sub != id {
+				continue
+			}
+			report("EOSE after %d events", count)
+			if !keepOpen {
+				return
+			}
+			eose = true
+		case "CLOSED":
+			if sub != id {
+				continue
+			}
+			var message string
+			if len(args) > 1 {
+				json.Unmarshal(args[1], &message)
+			}
+			report("CLOSED after %d events: %s", count, message)
+			return
+		case "NOTICE":
+			report("NOTICE: %s", sub)
+		case "AUTH":
+			report("AUTH requested (NIP-42), not supported")
+		}
+	}
+}
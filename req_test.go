@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReqFilters(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+		err  bool
+	}{
+		{nil, nil, false},
+		{[]string{`{"kinds":[1],"limit":5}`}, []string{`{"kinds":[1],"limit":5}`}, false},
+		// kept as written, fields unknown to us included
+		{[]string{`{"search":"nostr", "#t" : ["go"]}`}, []string{`{"search":"nostr", "#t" : ["go"]}`}, false},
+		{[]string{`[{"kinds":[0]},{"ids":["ab"]}]`}, []string{`{"kinds":[0]}`, `{"ids":["ab"]}`}, false},
+		{[]string{`{"kinds":[0]} {"kinds":[3]}`, `{"limit":1}`}, []string{`{"kinds":[0]}`, `{"kinds":[3]}`, `{"limit":1}`}, false},
+		{[]string{`{"kinds":[1]`}, nil, true},
+		{[]string{`kinds=1`}, nil, true},
+		{[]string{`1`}, nil, true},
+		{[]string{`[{"kinds":[1]},"x"]`}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := reqFilters(tt.args)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: error %v", tt.args, err)
+			continue
+		}
+		var want []json.RawMessage
+		for _, f := range tt.want {
+			want = append(want, json.RawMessage(f))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: filters %q, want %q", tt.args, got, want)
+		}
+	}
+}
+
+func TestReqFiltersStdin(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "filters")
+	if err := os.WriteFile(name, []byte("{\"kinds\":[1]}\n[{\"kinds\":[7]}]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	defer func(stdin *os.File) { os.Stdin = stdin }(os.Stdin)
+	os.Stdin = f
+
+	got, err := reqFilters([]string{`{"limit":1}`, "-"})
+	want := []json.RawMessage{json.RawMessage(`{"limit":1}`), json.RawMessage(`{"kinds":[1]}`), json.RawMessage(`{"kinds":[7]}`)}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("filters %q, %v, want %q", got, err, want)
+	}
+}
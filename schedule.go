@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	scheduleFile            = "schedule.json"
+	defaultScheduleInterval = 30 * time.Second
+)
+
+// scheduledPost is a signed event waiting in the queue until At.
+type scheduledPost struct {
+	Event nostr.Event `json:"event"`
+	At    time.Time   `json:"at"`
+}
+
+// scheduleTimeLayouts are the accepted formats for --at, in local time
+// unless they carry a zone.
+var scheduleTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseScheduleTime parses an --at value as one of scheduleTimeLayouts or a
+// unix timestamp.
+func parseScheduleTime(s string) (time.Time, error) {
+	for _, layout := range scheduleTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("can't parse time %q, use e.g. 2026-11-01T09:00", s)
+}
+
+// scheduleMu serializes changes to the schedule within the process. Use
+// lockSchedule, which also keeps other processes out.
+var scheduleMu sync.Mutex
+
+// lockSchedule keeps the daemon, the TUI and one-off commands sharing a
+// datadir from writing over each other's changes to the schedule. Call the
+// returned function to release it.
+func lockSchedule() (unlock func()) {
+	return lockDataFile(scheduleFile, &scheduleMu)
+}
+
+// readSchedule loads the schedule for display.
+func readSchedule() []scheduledPost {
+	unlock := lockSchedule()
+	defer unlock()
+	return loadSchedule()
+}
+
+// loadSchedule returns the queued posts ordered by due time.
+func loadSchedule() []scheduledPost {
+	var queue []scheduledPost
+	if err := loadDataFile(scheduleFile, &queue); err != nil {
+		log.Printf("can't read %s: %s", scheduleFile, err)
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].At.Before(queue[j].At)
+	})
+	return queue
+}
+
+// schedulePost signs event with created_at set to at and queues it.
+func schedulePost(event nostr.Event, at time.Time) (nostr.Event, error) {
+	event.CreatedAt = at
+	if err := signEvent(&event); err != nil {
+		return event, err
+	}
+	unlock := lockSchedule()
+	defer unlock()
+	queue := append(loadSchedule(), scheduledPost{Event: event, At: at})
+	return event, saveDataFile(scheduleFile, queue)
+}
+
+// cancelScheduled removes the queued post whose event ID starts with id.
+func cancelScheduled(id string) (bool, error) {
+	unlock := lockSchedule()
+	defer unlock()
+	queue := loadSchedule()
+	for i, item := range queue {
+		if id != "" && len(id) <= len(item.Event.ID) && item.Event.ID[:len(id)] == id {
+			queue = append(queue[:i], queue[i+1:]...)
+			return true, saveDataFile(scheduleFile, queue)
+		}
+	}
+	return false, nil
+}
+
+// duePublish is one scheduled post handed to the relays.
+type duePublish struct {
+	event    *nostr.Event
+	statuses chan deliveryStatus
+	err      error
+}
+
+// publishDueScheduled publishes every queued post whose time has come and
+// removes it from the queue. Posts that can't be published, e.g. for lack
+// of write relays, stay queued and are tried again next time.
+func publishDueScheduled() []duePublish {
+	unlock := lockSchedule()
+	defer unlock()
+	queue := loadSchedule()
+	now := time.Now()
+	var due []duePublish
+	var rest []scheduledPost
+	for _, item := range queue {
+		if item.At.After(now) {
+			rest = append(rest, item)
+			continue
+		}
+		ev := item.Event
+		event, statuses, err := publishEvent(&ev)
+		if err != nil {
+			rest = append(rest, item)
+			event = &ev
+		}
+		due = append(due, duePublish{event: event, statuses: statuses, err: err})
+	}
+	if len(rest) < len(queue) {
+		if err := saveDataFile(scheduleFile, rest); err != nil {
+			log.Printf("can't write %s: %s", scheduleFile, err)
+		}
+	}
+	return due
+}
+
+func listScheduled(opts docopt.Opts) {
+	if id, _ := opts.String("<id>"); id != "" {
+		ok, err := cancelScheduled(id)
+		if err != nil {
+			log.Printf("Failed to cancel: %s.\n", err)
+			return
+		}
+		if !ok {
+			log.Printf("No scheduled post %s.\n", id)
+			return
+		}
+		fmt.Printf("Cancelled %s.\n", id)
+		return
+	}
+	queue := readSchedule()
+	if len(queue) == 0 {
+		fmt.Println("Nothing scheduled.")
+		return
+	}
+	for _, item := range queue {
+		fmt.Printf("%s  %s (%s)\n  %s\n", shorten(item.Event.ID), item.At.Format("2006-01-02 15:04"),
+			humanize.Time(item.At), item.Event.Content)
+	}
+}
+
+// daemon publishes due scheduled posts and retries the outbox until interrupted.
+func daemon(opts docopt.Opts) {
+	interval := defaultScheduleInterval
+	if secs, err := opts.Int("--interval"); err == nil && secs > 0 {
+		interval = time.Duration(secs) * time.Second
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Publishing scheduled posts every %s.", interval)
+	for {
+		for _, p := range publishDueScheduled() {
+			if p.err != nil {
+				log.Printf("Error publishing %s: %s; it stays scheduled.\n", p.event.ID, p.err)
+				continue
+			}
+			printPublishStatus(p.event, p.statuses)
+		}
+		if delivered, failed := flushOutbox(false); delivered+failed > 0 {
+			log.Printf("Outbox: delivered %d, failed %d.\n", delivered, failed)
+		}
+		select {
+		case <-ticker.C:
+		case <-sigs:
+			return
+		}
+	}
+}
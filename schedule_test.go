@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestScheduleConcurrentChanges(t *testing.T) {
+	defer func(c Config) { config = c }(config)
+	config.DataDir = t.TempDir()
+	config.PrivateKey = testPrivateKey
+	config.Relays = nil
+
+	past := time.Now().Add(-time.Minute)
+	due, err := schedulePost(nostr.Event{Kind: nostr.KindTextNote, Content: "due"}, past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// another process holding the lock keeps changes waiting
+	f, err := os.OpenFile(filepath.Join(config.DataDir, scheduleFile+".lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			at := time.Now().Add(time.Duration(i+1) * time.Hour)
+			if _, err := schedulePost(nostr.Event{Kind: nostr.KindTextNote, Content: "later"}, at); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("scheduled while another process held the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlockFile(f)
+	<-done
+
+	// without write relays the due post can't be published and stays queued
+	published := publishDueScheduled()
+	if len(published) != 1 || published[0].err == nil || published[0].event.ID != due.ID {
+		t.Errorf("published %+v, want the due post failing", published)
+	}
+	queue := readSchedule()
+	if len(queue) != 9 || queue[0].Event.ID != due.ID {
+		t.Errorf("%d posts queued, first %s, want 9 with %s first", len(queue), queue[0].Event.ID, due.ID)
+	}
+
+	ok, err := cancelScheduled(due.ID[:8])
+	if !ok || err != nil {
+		t.Errorf("cancelling: %v, %v", ok, err)
+	}
+	if queue := readSchedule(); len(queue) != 8 {
+		t.Errorf("%d posts queued after cancelling, want 8", len(queue))
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip11"
+)
+
+const (
+	searchLimit      = 50
+	searchTimeout    = 6 * time.Second
+	relayInfoTimeout = 3 * time.Second
+	relayInfoMaxAge  = time.Hour
+	relayInfoMaxSize = 64 * 1024 // bytes of a NIP-11 document read
+)
+
+// searchQuery is a full-text search, optionally narrowed like a filter.
+type searchQuery struct {
+	Text   string
+	Author string // hex pubkey
+	Kinds  []int  // default: notes and articles
+	Since  time.Time
+	Limit  int
+}
+
+// searchResult is a matching event and where it was found.
+type searchResult struct {
+	Event  nostr.Event
+	Score  float64
+	Local  bool     // in the local index
+	Relays []string // NIP-50 relays that returned it
+}
+
+func (q searchQuery) kinds() []int {
+	if len(q.Kinds) > 0 {
+		return q.Kinds
+	}
+	return []int{nostr.KindTextNote, KindLongForm}
+}
+
+// filter is the relay filter of q, without the search term the go-nostr
+// filter has no field for.
+func (q searchQuery) filter() nostr.Filter {
+	filter := nostr.Filter{Kinds: q.kinds(), Limit: q.Limit}
+	if q.Author != "" {
+		filter.Authors = []string{q.Author}
+	}
+	if !q.Since.IsZero() {
+		since := q.Since
+		filter.Since = &since
+	}
+	return filter
+}
+
+// allows applies the constraints of q other than the text.
+func (q searchQuery) allows(ev nostr.Event) bool {
+	if q.Author != "" && ev.PubKey != q.Author {
+		return false
+	}
+	if !q.Since.IsZero() && ev.CreatedAt.Before(q.Since) {
+		return false
+	}
+	for _, kind := range q.kinds() {
+		if ev.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// relay information documents by relay URL
+var relayInfoCache struct {
+	sync.Mutex
+	docs map[string]relayInfoEntry
+}
+
+type relayInfoEntry struct {
+	doc     nip11.RelayInformationDocument
+	err     error
+	fetched time.Time
+}
+
+// relayInfo fetches the NIP-11 information document of the relay at url.
+// Answers are kept for relayInfoMaxAge.
+func relayInfo(url string) (nip11.RelayInformationDocument, error) {
+	url = nostr.NormalizeURL(url)
+	relayInfoCache.Lock()
+	entry, ok := relayInfoCache.docs[url]
+	relayInfoCache.Unlock()
+	if ok && time.Since(entry.fetched) < relayInfoMaxAge {
+		return entry.doc, entry.err
+	}
+
+	entry = relayInfoEntry{fetched: time.Now()}
+	entry.doc, entry.err = fetchRelayInfo(url)
+	relayInfoCache.Lock()
+	if relayInfoCache.docs == nil {
+		relayInfoCache.docs = make(map[string]relayInfoEntry)
+	}
+	relayInfoCache.docs[url] = entry
+	relayInfoCache.Unlock()
+	return entry.doc, entry.err
+}
+
+func fetchRelayInfo(url string) (nip11.RelayInformationDocument, error) {
+	var doc nip11.RelayInformationDocument
+	ctx, cancel := context.WithTimeout(context.Background(), relayInfoTimeout)
+	defer cancel()
+	// ws://host is served as http://host, wss:// as https://
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http"+strings.TrimPrefix(url, "ws"), nil)
+	if err != nil {
+		return doc, err
+	}
+	req.Header.Set("Accept", "application/nostr+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return doc, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return doc, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, relayInfoMaxSize)).Decode(&doc)
+	return doc, err
+}
+
+func supportsNIP(doc nip11.RelayInformationDocument, nip int) bool {
+	for _, n := range doc.SupportedNIPs {
+		if n == nip {
+			return true
+		}
+	}
+	return false
+}
+
+// searchRelays returns the read relays that advertise NIP-50 search.
+func searchRelays() []string {
+	var urls []string
+	for url, policy := range config.Relays {
+		if policy.Read {
+			urls = append(urls, url)
+		}
+	}
+	supported := make([]bool, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			doc, err := relayInfo(url)
+			supported[i] = err == nil && supportsNIP(doc, 50)
+		}(i, url)
+	}
+	wg.Wait()
+	var relays []string
+	for i, url := range urls {
+		if supported[i] {
+			relays = append(relays, url)
+		}
+	}
+	sort.Strings(relays)
+	return relays
+}
+
+// searchRelay sends q to the relay at url as a NIP-50 search and collects
+// the events until the relay has sent all it has.
+func searchRelay(url string, q searchQuery) ([]nostr.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+	w, err := dialRelay(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		w.conn.SetReadDeadline(deadline)
+	}
+
+	// add "search" to the filter as sent on the wire
+	raw, err := json.Marshal(q.filter())
+	if err != nil {
+		return nil, err
+	}
+	var filter map[string]interface{}
+	if err := json.Unmarshal(raw, &filter); err != nil {
+		return nil, err
+	}
+	filter["search"] = q.Text
+	id := subscriptionID("search")
+	if err := w.write("REQ", id, filter); err != nil {
+		return nil, err
+	}
+	defer w.write("CLOSE", id)
+
+	var events []nostr.Event
+	for {
+		label, args, err := w.read()
+		if err != nil {
+			// a relay that never sends EOSE still gave us something
+			if len(events) > 0 {
+				return events, nil
+			}
+			return nil, err
+		}
+		var sub string
+		if len(args) > 0 {
+			json.Unmarshal(args[0], &sub)
+		}
+		switch label {
+		case "EVENT":
+			if sub != id || len(args) < 2 {
+				continue
+			}
+			var ev nostr.Event
+			if err := json.Unmarshal(args[1], &ev); err != nil {
+				continue
+			}
+			if ok, _ := ev.CheckSignature(); ok && q.allows(ev) {
+				events = append(events, ev)
+			}
+		case "EOSE":
+			if sub == id {
+				return events, nil
+			}
+		case "CLOSED":
+			if sub != id {
+				continue
+			}
+			var message string
+			if len(args) > 1 {
+				json.Unmarshal(args[1], &message)
+			}
+			return events, errors.New("closed: " + message)
+		}
+	}
+}
+
+// runSearch asks the NIP-50 relays and the local index for q at once and
+// merges the results, best first. It also returns the relays asked.
+// Remote results are added to the local cache.
+func runSearch(q searchQuery) ([]searchResult, []string) {
+	if q.Limit <= 0 {
+		q.Limit = searchLimit
+	}
+	var local []nostr.Event
+	done := make(chan struct{})
+	go func() {
+		local = localIndex().search(q)
+		close(done)
+	}()
+
+	relays := searchRelays()
+	remote := make([][]nostr.Event, len(relays))
+	var wg sync.WaitGroup
+	for i, url := range relays {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			events, err := searchRelay(url, q)
+			if err != nil {
+				log.Printf("Search on %s: %s.\n", url, err)
+			}
+			remote[i] = events
+		}(i, url)
+	}
+	wg.Wait()
+	<-done
+
+	found := make(map[string]*searchResult)
+	var fetched []nostr.Event
+	for _, ev := range local {
+		found[ev.ID] = &searchResult{Event: ev, Local: true}
+	}
+	for i, events := range remote {
+		for _, ev := range events {
+			r, ok := found[ev.ID]
+			if !ok {
+				r = &searchResult{Event: ev}
+				found[ev.ID] = r
+				fetched = append(fetched, ev)
+			}
+			r.Relays = append(r.Relays, relays[i])
+		}
+	}
+	cacheEvents(fetched)
+
+	idx := localIndex()
+	tokens := tokenize(q.Text)
+	mutes := currentMuteFilter()
+	results := make([]searchResult, 0, len(found))
+	for _, r := range found {
+		if mutes.muted(r.Event) {
+			continue
+		}
+		// word matches count most; every relay that ranked it adds a little
+		r.Score = idx.score(r.Event, tokens) + 0.1*float64(len(r.Relays))
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Event.CreatedAt.After(results[j].Event.CreatedAt)
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, relays
+}
+
+func searchNotes(opts docopt.Opts) {
+	q := searchQuery{Text: strings.Join(opts["<query>"].([]string), " ")}
+	if len(tokenize(q.Text)) == 0 {
+		log.Println("Nothing to search for.")
+		return
+	}
+	if arg, _ := opts.String("--author"); arg != "" {
+		key, err := pubkeyArg(arg)
+		if err != nil {
+			log.Printf("%s: %s.\n", arg, err)
+			return
+		}
+		q.Author = key
+	}
+	if arg, _ := opts.String("--kind"); arg != "" {
+		kind, err := strconv.Atoi(arg)
+		if err != nil {
+			log.Printf("Invalid kind %s.\n", arg)
+			return
+		}
+		q.Kinds = []int{kind}
+	}
+	if arg, _ := opts.String("--since"); arg != "" {
+		since, err := parseScheduleTime(arg)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		q.Since = since
+	}
+	q.Limit, _ = opts.Int("--limit")
+	verbose, _ := opts.Bool("--verbose")
+	jsonformat, _ := opts.Bool("--json")
+	initNostr()
+
+	results, relays := runSearch(q)
+	if len(relays) == 0 && !jsonformat {
+		fmt.Println("No configured relay supports NIP-50 search; searching the local cache only.")
+	}
+	if len(results) == 0 {
+		fmt.Println("Nothing found.")
+		return
+	}
+	nameMap := make(map[string]string)
+	for _, follow := range config.Following {
+		if follow.Name != "" {
+			nameMap[follow.Key] = follow.Name
+		}
+	}
+	for _, r := range results {
+		nick := nameMap[r.Event.PubKey]
+		printEvent(r.Event, &nick, verbose, jsonformat)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFetchRelayInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/nostr+json" {
+			http.Error(w, "not a NIP-11 request", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/huge":
+			fmt.Fprintf(w, `{"description":%q,"supported_nips":[1,50]}`, strings.Repeat("x", relayInfoMaxSize))
+		default:
+			fmt.Fprint(w, `{"name":"test","supported_nips":[1,11,50]}`)
+		}
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	doc, err := fetchRelayInfo(url)
+	if err != nil || doc.Name != "test" || !reflect.DeepEqual(doc.SupportedNIPs, []int{1, 11, 50}) {
+		t.Errorf("relay info %+v, %v", doc, err)
+	}
+	if !supportsNIP(doc, 50) || supportsNIP(doc, 42) {
+		t.Errorf("supportsNIP wrong for %v", doc.SupportedNIPs)
+	}
+	// documents are cut off
+	if doc, err := fetchRelayInfo(url + "/huge"); err == nil {
+		t.Errorf("read a document of more than %d bytes: %+v", relayInfoMaxSize, doc.SupportedNIPs)
+	}
+}
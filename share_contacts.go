@@ -2,10 +2,8 @@ package main
 
 import (
 	"log"
-	"time"
 
 	"github.com/docopt/docopt-go"
-	"github.com/nbd-wtf/go-nostr"
 )
 
 func shareContacts(opts docopt.Opts) {
@@ -19,24 +17,7 @@ func shareContacts(opts docopt.Opts) {
 		return
 	}
 
-	initNostr()
-
-	var tags nostr.Tags
-	for _, follow := range config.Following {
-		relay := ""
-		if len(follow.Relays) > 0 {
-			relay = follow.Relays[0]
-		}
-		tag := nostr.Tag{"p", follow.Key, relay, follow.Name}
-		tags = append(tags, tag)
-	}
-
-	event, statuses, err := pool.PublishEvent(&nostr.Event{
-		CreatedAt: time.Now(),
-		Kind:      nostr.KindContactList,
-		Tags:      tags,
-		Content:   "",
-	})
+	event, statuses, err := pushContacts("")
 	if err != nil {
 		log.Printf("Error publishing: %s.\n", err.Error())
 		return
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/gorilla/websocket"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+const (
+	streamDialTimeout = 10 * time.Second
+	streamPing        = 30 * time.Second
+	streamIdle        = 90 * time.Second // no pong this long: the relay is gone
+	streamBackoffMin  = time.Second
+	streamBackoffMax  = time.Minute
+	streamSeenMax     = 10000 // event IDs remembered to drop repeats
+)
+
+// streamClosed is the relay ending the subscription with CLOSED.
+type streamClosed struct{ message string }
+
+func (c streamClosed) Error() string { return "closed: " + c.message }
+
+// streamEvent is what a --template is executed with.
+type streamEvent struct {
+	nostr.Event
+	Name string // petname of the author, if followed
+}
+
+// recentIDs remembers the last IDs added to it.
+type recentIDs struct {
+	ids   map[string]bool
+	order []string
+	max   int
+}
+
+func newRecentIDs(max int) *recentIDs {
+	return &recentIDs{ids: make(map[string]bool), max: max}
+}
+
+// add tells whether id is new, remembering it.
+func (r *recentIDs) add(id string) bool {
+	if r.ids[id] {
+		return false
+	}
+	r.ids[id] = true
+	r.order = append(r.order, id)
+	if len(r.order) > r.max {
+		delete(r.ids, r.order[0])
+		r.order = r.order[1:]
+	}
+	return true
+}
+
+// subscriptionID returns a random subscription ID starting with prefix.
+func subscriptionID(prefix string) string {
+	random := make([]byte, 7)
+	rand.Read(random)
+	return prefix + "-" + hex.EncodeToString(random)
+}
+
+// streamFilter builds the filter of 'noscl stream' from its options.
+func streamFilter(opts docopt.Opts) (nostr.Filter, error) {
+	var filter nostr.Filter
+	kinds, _ := optSlice(opts, "--kinds")
+	for _, arg := range kinds {
+		for _, k := range strings.Split(arg, ",") {
+			kind, err := strconv.Atoi(strings.TrimSpace(k))
+			if err != nil {
+				return filter, fmt.Errorf("invalid kind %s", k)
+			}
+			filter.Kinds = append(filter.Kinds, kind)
+		}
+	}
+	authors, _ := optSlice(opts, "--authors")
+	for _, arg := range authors {
+		key, err := pubkeyArg(arg)
+		if err != nil {
+			return filter, fmt.Errorf("%s: %w", arg, err)
+		}
+		filter.Authors = append(filter.Authors, key)
+	}
+	tags, _ := optSlice(opts, "--tags")
+	for _, arg := range tags {
+		name, values, ok := strings.Cut(arg, "=")
+		if !ok || len(name) != 1 || values == "" {
+			return filter, fmt.Errorf("invalid tag %s, use e.g. t=nostr", arg)
+		}
+		if filter.Tags == nil {
+			filter.Tags = nostr.TagMap{}
+		}
+		filter.Tags[name] = append(filter.Tags[name], strings.Split(values, ",")...)
+	}
+	if arg, _ := opts.String("--since"); arg != "" {
+		since, err := parseScheduleTime(arg)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = &since
+	}
+	filter.Limit, _ = opts.Int("--limit")
+	if filter.Since == nil && filter.Limit == 0 {
+		// like tail -f: only what comes from now on
+		now := time.Now()
+		filter.Since = &now
+	}
+	return filter, nil
+}
+
+// streamPrinter returns the function printing each event in format.
+func streamPrinter(format, tmpl string) (func(nostr.Event), error) {
+	nameMap := make(map[string]string)
+	for _, follow := range config.Following {
+		if follow.Name != "" {
+			nameMap[follow.Key] = follow.Name
+		}
+	}
+	switch format {
+	case "", "text":
+		return func(ev nostr.Event) {
+			nick := nameMap[ev.PubKey]
+			printEvent(ev, &nick, false, false)
+		}, nil
+	case "json":
+		// one event per line (NDJSON)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		return func(ev nostr.Event) {
+			enc.Encode(ev)
+		}, nil
+	case "gopher":
+		headerPrinted := false
+		return func(ev nostr.Event) {
+			if !headerPrinted {
+				printGostrHeader()
+				headerPrinted = true
+			}
+			nick := nameMap[ev.PubKey]
+			for _, line := range formatAsGopher(ev, &nick) {
+				fmt.Printf("%s\r\n", line)
+			}
+		}, nil
+	case "template":
+		if tmpl == "" {
+			return nil, errors.New("--format=template needs --template")
+		}
+		t, err := template.New("event").Parse(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		return func(ev nostr.Event) {
+			if err := t.Execute(os.Stdout, streamEvent{ev, nameMap[ev.PubKey]}); err != nil {
+				log.Println(err)
+			}
+			fmt.Println()
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown format %s, use json, text, gopher or template", format)
+}
+
+func stream(opts docopt.Opts) {
+	filter, err := streamFilter(opts)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	format, _ := opts.String("--format")
+	tmpl, _ := opts.String("--template")
+	if format == "" && tmpl != "" {
+		format = "template"
+	}
+	show, err := streamPrinter(format, tmpl)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	var urls []string
+	for url, policy := range config.Relays {
+		if policy.Read {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 {
+		log.Println("No read relays configured.")
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	events := make(chan nostr.Event)
+	var wg sync.WaitGroup
+	for _, url := range urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			streamRelay(ctx, url, filter, events)
+		}(url)
+	}
+	relaysDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(relaysDone)
+	}()
+	// every relay sends the same events, and again after reconnecting
+	seen := newRecentIDs(streamSeenMax)
+	for {
+		select {
+		case ev := <-events:
+			if seen.add(ev.ID) {
+				show(ev)
+			}
+		case <-relaysDone:
+			if ctx.Err() == nil {
+				log.Println("No relay left to stream from.")
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// streamRelay passes on the events matching filter from the relay at url
+// until ctx is done, reconnecting when the connection drops. After a
+// reconnect it only asks for what is newer than the last event it got. It
+// gives up when the relay refuses the subscription with CLOSED, unless the
+// reason is rate limiting: then it keeps backing off.
+func streamRelay(ctx context.Context, url string, filter nostr.Filter, events chan<- nostr.Event) {
+	backoff := streamBackoffMin
+	for {
+		newest, connected, err := streamOnce(ctx, url, filter, events)
+		if ctx.Err() != nil {
+			return
+		}
+		if !newest.IsZero() && (filter.Since == nil || newest.After(*filter.Since)) {
+			filter.Since = &newest
+		}
+		var closed streamClosed
+		if errors.As(err, &closed) {
+			if !strings.HasPrefix(closed.message, "rate-limited:") {
+				log.Printf("%s: %s; not asking again.\n", url, err)
+				return
+			}
+			// asking again right away would only be refused again
+			connected = false
+		}
+		if connected {
+			backoff = streamBackoffMin
+		}
+		log.Printf("%s: %s; reconnecting in %s.\n", url, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if !connected && backoff < streamBackoffMax {
+			backoff *= 2
+		}
+	}
+}
+
+// streamOnce subscribes on one connection to url and returns when it ends,
+// with the time of the newest event passed on.
+func streamOnce(ctx context.Context, url string, filter nostr.Filter, events chan<- nostr.Event) (newest time.Time, connected bool, err error) {
+	dialCtx, cancel := context.WithTimeout(ctx, streamDialTimeout)
+	w, err := dialRelay(dialCtx, url)
+	cancel()
+	if err != nil {
+		return newest, false, err
+	}
+	defer w.Close()
+
+	// ping the relay so that a dead connection is noticed
+	done := make(chan struct{})
+	defer close(done)
+	w.conn.SetReadDeadline(time.Now().Add(streamIdle))
+	w.conn.SetPongHandler(func(string) error {
+		return w.conn.SetReadDeadline(time.Now().Add(streamIdle))
+	})
+	go func() {
+		ticker := time.NewTicker(streamPing)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamPing))
+			case <-ctx.Done():
+				// ends the read below
+				w.Close()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	id := subscriptionID("stream")
+	if err := w.write("REQ", id, filter); err != nil {
+		return newest, false, err
+	}
+	for {
+		label, args, err := w.read()
+		if err != nil {
+			return newest, true, err
+		}
+		w.conn.SetReadDeadline(time.Now().Add(streamIdle))
+		var sub string
+		if len(args) > 0 {
+			json.Unmarshal(args[0], &sub)
+		}
+		switch label {
+		case "EVENT":
+			if sub != id || len(args) < 2 {
+				continue
+			}
+			var ev nostr.Event
+			if err := json.Unmarshal(args[1], &ev); err != nil {
+				continue
+			}
+			if ok, _ := ev.CheckSignature(); !ok || !filter.Matches(&ev) {
+				continue
+			}
+			// events dated in the future must not hide the present
+			if ev.CreatedAt.After(newest) && ev.CreatedAt.Before(time.Now()) {
+				newest = ev.CreatedAt
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return newest, true, ctx.Err()
+			}
+		case "CLOSED":
+			if sub != id {
+				continue
+			}
+			var message string
+			if len(args) > 1 {
+				json.Unmarshal(args[1], &message)
+			}
+			return newest, true, streamClosed{message}
+		case "NOTICE":
+			log.Printf("%s: notice: %s\n", url, sub)
+		}
+	}
+}
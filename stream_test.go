@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+func TestRecentIDs(t *testing.T) {
+	r := newRecentIDs(3)
+	steps := []struct {
+		id  string
+		new bool
+	}{
+		{"a", true}, {"a", false}, {"b", true}, {"c", true}, {"a", false},
+		{"d", true}, // forgets a
+		{"a", true}, // forgets b
+		{"c", false}, {"b", true},
+	}
+	for i, step := range steps {
+		if got := r.add(step.id); got != step.new {
+			t.Errorf("step %d: add(%q) = %v, want %v", i, step.id, got, step.new)
+		}
+	}
+	if len(r.ids) != 3 || len(r.order) != 3 {
+		t.Errorf("remembers %d ids in order %v, want 3", len(r.ids), r.order)
+	}
+}
+
+func TestStreamFilter(t *testing.T) {
+	key := "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	npub, _ := nip19.EncodePublicKey(key, "")
+	since := time.Unix(1700000000, 0)
+	tests := []struct {
+		args string
+		want nostr.Filter
+		now  bool // since now, as no --since or --limit was given
+		err  bool
+	}{
+		{"", nostr.Filter{}, true, false},
+		{"--kinds=1,7 --kinds=30023", nostr.Filter{Kinds: []int{1, 7, 30023}}, true, false},
+		{"--kinds=1,x", nostr.Filter{}, false, true},
+		{"--authors=" + strings.ToUpper(key) + " --authors=" + npub, nostr.Filter{Authors: []string{key, key}}, true, false},
+		{"--authors=nobody", nostr.Filter{}, false, true},
+		{"--tags=t=nostr,go --tags=p=" + key + " --tags=t=bitcoin",
+			nostr.Filter{Tags: nostr.TagMap{"t": {"nostr", "go", "bitcoin"}, "p": {key}}}, true, false},
+		{"--tags=tt=x", nostr.Filter{}, false, true},
+		{"--tags=t=", nostr.Filter{}, false, true},
+		{"--tags=t", nostr.Filter{}, false, true},
+		{"--limit=20", nostr.Filter{Limit: 20}, false, false},
+		{"--since=1700000000", nostr.Filter{Since: &since}, false, false},
+		{"--since=1700000000 --limit=5", nostr.Filter{Since: &since, Limit: 5}, false, false},
+		{"--since=yesterday", nostr.Filter{}, false, true},
+	}
+	for _, tt := range tests {
+		opts, err := docopt.ParseArgs(USAGE, append([]string{"stream"}, strings.Fields(tt.args)...), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := time.Now()
+		got, err := streamFilter(opts)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: error %v", tt.args, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tt.now {
+			if got.Since == nil || got.Since.Before(before.Truncate(time.Second)) || got.Since.After(time.Now()) {
+				t.Errorf("%q: since %v, want now", tt.args, got.Since)
+			}
+			got.Since = nil
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: filter %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const maxSuggestedFollows = 50
+
+// followCandidate is someone we might follow.
+type followCandidate struct {
+	Follow
+	Count int // how many of our follows follow them
+	Meta  Metadata
+}
+
+// displayName returns the best name we have for the candidate.
+func (c followCandidate) displayName() string {
+	switch {
+	case c.Meta.Name != "":
+		return c.Meta.Name
+	case c.Name != "":
+		return c.Name
+	}
+	return shorten(c.Key)
+}
+
+// fetchNewest returns the newest event of kind by each of pubkeys.
+// The pool must be initialized.
+func fetchNewest(pubkeys []string, kind int) map[string]nostr.Event {
+	newest, _ := queryNewest(pubkeys, kind)
+	return newest
+}
+
+// queryNewest is fetchNewest for callers that must not take a missing
+// event for an absent one: it fails with errNoReply when no relay replied.
+func queryNewest(pubkeys []string, kind int) (map[string]nostr.Event, error) {
+	newest := make(map[string]nostr.Event)
+	if len(pubkeys) == 0 {
+		return newest, nil
+	}
+	sub := subscribePool(authorFilters(nostr.Filter{Kinds: []int{kind}}, pubkeys))
+	defer sub.Close()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+		if ev.Kind != kind {
+			continue
+		}
+		if prev, ok := newest[ev.PubKey]; !ok || ev.CreatedAt.After(prev.CreatedAt) {
+			newest[ev.PubKey] = ev
+		}
+	}
+	if !sub.answered() {
+		return newest, errNoReply
+	}
+	return newest, nil
+}
+
+// fetchProfiles returns the kind-0 metadata of pubkeys.
+func fetchProfiles(pubkeys []string) map[string]Metadata {
+	profiles := make(map[string]Metadata)
+	for key, ev := range fetchNewest(pubkeys, nostr.KindSetMetadata) {
+		var meta Metadata
+		if err := json.Unmarshal([]byte(ev.Content), &meta); err == nil {
+			profiles[key] = meta
+		}
+	}
+	return profiles
+}
+
+// withProfiles fills in the metadata of candidates.
+func withProfiles(candidates []followCandidate) []followCandidate {
+	keys := make([]string, len(candidates))
+	for i, c := range candidates {
+		keys[i] = c.Key
+	}
+	profiles := fetchProfiles(keys)
+	for i := range candidates {
+		candidates[i].Meta = profiles[candidates[i].Key]
+	}
+	return candidates
+}
+
+// followsOf returns the contacts of pubkey we don't follow yet, by name.
+func followsOf(pubkey string) ([]followCandidate, error) {
+	ev, ok := fetchNewest([]string{pubkey}, nostr.KindContactList)[pubkey]
+	if !ok {
+		return nil, fmt.Errorf("no contact list found for %s", shorten(pubkey))
+	}
+	self := ""
+	if config.PrivateKey != "" {
+		self = getPubKey(config.PrivateKey)
+	}
+	var candidates []followCandidate
+	for key, f := range parseContactList(&ev) {
+		if _, ok := config.Following[key]; ok || key == self {
+			continue
+		}
+		candidates = append(candidates, followCandidate{Follow: f})
+	}
+	candidates = withProfiles(candidates)
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := strings.ToLower(candidates[i].displayName()), strings.ToLower(candidates[j].displayName())
+		if a != b {
+			return a < b
+		}
+		return candidates[i].Key < candidates[j].Key
+	})
+	return candidates, nil
+}
+
+// suggestFollows ranks the pubkeys our follows follow, but we don't, by the
+// number of our follows following them.
+func suggestFollows() []followCandidate {
+	keys := make([]string, 0, len(config.Following))
+	for key := range config.Following {
+		keys = append(keys, key)
+	}
+	self := ""
+	if config.PrivateKey != "" {
+		self = getPubKey(config.PrivateKey)
+	}
+	counts := make(map[string]int)
+	hints := make(map[string]Follow)
+	for _, ev := range fetchNewest(keys, nostr.KindContactList) {
+		ev := ev
+		for key, f := range parseContactList(&ev) {
+			if _, ok := config.Following[key]; ok || key == self {
+				continue
+			}
+			counts[key]++
+			// petnames are someone else's; only the relay hints are useful
+			hint := hints[key]
+			hint.Key = key
+			hints[key] = mergeFollow(hint, Follow{Relays: f.Relays})
+		}
+	}
+	candidates := make([]followCandidate, 0, len(counts))
+	for key, n := range counts {
+		candidates = append(candidates, followCandidate{Follow: hints[key], Count: n})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Count != candidates[j].Count {
+			return candidates[i].Count > candidates[j].Count
+		}
+		return candidates[i].Key < candidates[j].Key
+	})
+	if len(candidates) > maxSuggestedFollows {
+		candidates = candidates[:maxSuggestedFollows]
+	}
+	return withProfiles(candidates)
+}
+
+// followCandidateEntry is what ends up in config.Following for c: their own
+// profile name is preferred over a petname someone else gave them.
+func followCandidateEntry(c followCandidate) Follow {
+	f := c.Follow
+	if c.Meta.Name != "" {
+		f.Name = c.Meta.Name
+	}
+	return f
+}
+
+func followFrom(opts docopt.Opts) {
+	from := nip19.TranslatePublicKey(opts["--from"].(string))
+	if from == "" {
+		log.Println("Invalid pubkey.")
+		return
+	}
+
+	initNostr()
+
+	candidates, err := followsOf(from)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(candidates) == 0 {
+		fmt.Println("You already follow everyone they follow.")
+		return
+	}
+
+	all, _ := opts.Bool("--yes")
+	stdin := bufio.NewReader(os.Stdin)
+	added := 0
+	for _, c := range candidates {
+		npub, _ := nip19.EncodePublicKey(c.Key, "")
+		if !all {
+			fmt.Printf("Follow %s (%s)? [y/N/a/q] ", c.displayName(), npub)
+			answer, _ := stdin.ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y":
+			case "a":
+				all = true
+			case "q":
+				fmt.Printf("Followed %d.\n", added)
+				return
+			default:
+				continue
+			}
+		}
+		config.Following[c.Key] = followCandidateEntry(c)
+		added++
+		if all {
+			fmt.Printf("Followed %s (%s).\n", c.displayName(), npub)
+		}
+	}
+	fmt.Printf("Followed %d.\n", added)
+}
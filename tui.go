@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/nbd-wtf/go-nostr"
 )
@@ -48,6 +50,16 @@ const (
 	screenComposeMessage
 	screenImageURLSelect
 	screenImageASCII
+	screenDrafts
+	screenPublishLog
+	screenContactSync
+	screenCandidates
+	screenProfile
+	screenEditProfile
+	screenBookmarks
+	screenLists
+	screenHashtags
+	screenSearch
 )
 
 const feedLimit = 25
@@ -59,23 +71,38 @@ type model struct {
 	menuCur      int
 	listCur      int
 	listOffset   int // first visible item index for scrolling
+	listFilter   string // shows only the events matching it
+	listFilterInput  textinput.Model
+	listFilterActive bool // the filter is being typed
 	relayCur     int
 	events       []nostr.Event
 	nameMap      map[string]string
 	likedMap     map[string]string   // target ev ID -> our reaction ev ID
 	boostedMap   map[string]string   // target ev ID -> our boost ev ID
 	loading      bool
-	inbox        bool
-	notesOnly    bool // when true (Home): show only top-level notes, no replies
-	aether       bool // when true: unfiltered notes from all
+	feed         Feed   // what the list shows
+	feedSeq      int    // bumped per load so late results of an old feed are dropped
+	feedReturn   screen // where leaving the list leads
+	feedOlder    bool   // fetching the page before the oldest event
+	feedEnd      bool   // the feed has no older events
+	feedLive     *liveSub      // subscription for notes newer than the list
+	feedNew      []nostr.Event // arrived live, shown on [.]
 	err          string
 	relayLines   []string
 	relayURLs    []string
-	followLines  []string
+	followKeys         []string // Following screen, sorted
+	followCur          int
+	followSortActivity bool                 // sort by last note instead of name
+	followActivity     map[string]time.Time // pubkey -> last note, loaded on demand
+	followEdit         string               // "name", "relays" or "lists" while editing
+	followEditInput    textinput.Model
+	followConfirm      string // pubkey waiting for a second x to unfollow
 	setKeyInput     textinput.Model
 	addRelayInput   textinput.Model
 	followInput     textinput.Model
-	composeInput       textinput.Model
+	composeInput       textinput.Model // single-line message input for DMs
+	composeArea        textarea.Model  // multi-line note composer
+	composePreview     bool
 	composeToInput     textinput.Model
 	composeFollowKeys  []string // sorted pubkeys from Following, for recipient selection
 	composeRecipientCur int
@@ -84,11 +111,78 @@ type model struct {
 	composeReplyTargetAuthor string   // author of target event (for p-tag)
 	composeReplyRootID       string   // thread root event ID
 	composeReplyRootAuthor   string   // root author pubkey
+	composeSuggestions       []suggestion // @mention / #hashtag completions for the token at the cursor
+	composeSuggestCur        int
+	composeDraftID           string // draft being edited, saved again on Esc
+	composeReturn            screen // where Esc / publish lead back to
+	drafts              []Draft
+	scheduled           []scheduledPost
+	draftCur            int
+	flash               string // one-line notice shown under the current screen until the next key
+	outboxPending       int
+	outboxFailed        int
+	publish             publishPanel // per-relay outcome of the last write action
+	publishLog          []outboxEntry
+	publishLogCur       int
+	syncLoading         bool
+	syncEvent           *nostr.Event      // our newest kind-3 list on the relays
+	syncNoReply         bool              // no relay replied, so syncEvent says nothing
+	syncRemote          map[string]Follow // follows parsed from syncEvent
+	syncDiff            contactDiff
+	candidates          []followCandidate // follow suggestions or someone's follows
+	candidateSel        map[string]bool
+	candidateCur        int
+	candidatesLoading   bool
+	candidatesFrom      string // whose follows are shown; empty for suggestions
+	candidatesReturn    screen
+	profilePubkey       string
+	profile             profileData
+	profileLoading      bool
+	profilePicture      string // ASCII rendering of the profile picture
+	profileCur          int
+	profileReturn       screen
+	profileDetailStack  []nostr.Event // thread to restore when going back to the detail view
+	editProfileInputs   []textinput.Model // one per metadataFields entry
+	editProfileInitial  []string          // input values as loaded or last published
+	editProfileFields   map[string]interface{}
+	editProfileFound    bool
+	editProfileLoading  bool
+	editProfileCur      int
+	bookmarks           []listItem
+	bookmarkNotes       map[string]nostr.Event // bookmarked notes by id
+	bookmarkCur         int
+	bookmarksLoading    bool
+	peopleLists         map[string]nip51List // ours, or listsFrom's while importing
+	listNames           []string
+	listsCur            int
+	listsLoading        bool
+	listsFrom           string // pubkey whose lists are shown for import
+	listsConfirm        string // list deleted by the next x
+	listsInput          textinput.Model
+	listsInputActive    bool
+	hashtags            []listItem // our interest list
+	hashtagCur          int
+	hashtagsLoading     bool
+	hashtagInput        textinput.Model
+	hashtagInputActive  bool
+	searchInput         textinput.Model
+	searchInputActive   bool
+	searchQuery         string
+	searchResults       []searchResult
+	searchRelays        []string // NIP-50 relays asked
+	searchCur           int
+	searchLoading       bool
+	searchSeq           int // identifies the newest search
 	detailStack         []nostr.Event // thread navigation: [root] or [root, reply1, ...]
 	detailReplies       []nostr.Event // replies to detailStack[len-1]
 	detailReplyCur      int           // selected reply index (j/k)
 	detailRepliesLoading bool
 	detailStatus       string
+	detailMuting       bool   // next key answers the mute prompt
+	detailTag          string // hashtag selected with #
+	detailTagNote      string // id of the note detailTag was selected on
+	detailReturn       screen // where leaving the thread root leads
+	detailLive         *liveSub // subscription for new replies
 	imageURLs          []string // URLs to choose from when [i] pressed
 	imageURLCur        int
 	imageASCIIContent  string // loaded ASCII art or error
@@ -98,13 +192,13 @@ type model struct {
 
 type backMsg struct{}
 type homeLoadedMsg struct {
+	seq        int // feedSeq of the request
 	events     []nostr.Event
 	nameMap    map[string]string
 	likedMap   map[string]string
 	boostedMap map[string]string
-	inbox      bool
-	aether     bool
 	errMsg     string
+	older      bool // a page of older events to append
 }
 type relayListMsg struct{ lines []string }
 type followListMsg struct{ lines []string }
@@ -112,7 +206,9 @@ type reactionDoneMsg struct {
 	err        error
 	action     string // "like" "unlike" "boost" "unboost"
 	targetID   string
-	ourEventID string
+	ourEventID string // event being deleted by unlike / unboost
+	event      *nostr.Event // the published reaction, boost or deletion
+	statuses   chan deliveryStatus
 }
 
 type repliesLoadedMsg struct {
@@ -121,6 +217,19 @@ type repliesLoadedMsg struct {
 	rootID  string // event ID we loaded replies for
 }
 
+type tickMsg struct{}
+type backgroundDoneMsg struct {
+	scheduledSent   int
+	scheduledFailed int
+	outboxPending   int
+	outboxFailed    int
+}
+
+type editorDoneMsg struct {
+	content string
+	err     error
+}
+
 type imageASCIILoadedMsg struct {
 	content string
 	err     error
@@ -128,8 +237,14 @@ type imageASCIILoadedMsg struct {
 
 func runTUI(configPath string) {
 	tuiConfigPath = configPath
+	// one pool for the whole session: commands run concurrently and keep
+	// subscriptions open on it
+	initNostr()
 	prog := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := prog.Run(); err != nil {
+	_, err := prog.Run()
+	// notes that arrived live and aren't written yet
+	saveEventCache()
+	if err != nil {
 		panic(err)
 	}
 }
@@ -150,11 +265,44 @@ func initialModel() model {
 	fi.Width = 60
 	fi.PromptStyle = tuiStyle.Base
 	fi.TextStyle = tuiStyle.Base
+	ca := textarea.New()
+	ca.Placeholder = "Your note..."
+	ca.ShowLineNumbers = false
+	ca.SetWidth(60)
+	ca.SetHeight(8)
+	ca.FocusedStyle.Base = tuiStyle.Base
+	ca.FocusedStyle.Text = tuiStyle.Base
+	ca.FocusedStyle.Prompt = tuiStyle.Base
+	ca.FocusedStyle.CursorLine = tuiStyle.Cursor
+	ca.BlurredStyle = ca.FocusedStyle
 	ci := textinput.New()
-	ci.Placeholder = "Your note..."
+	ci.Placeholder = "Your message..."
 	ci.Width = 60
 	ci.PromptStyle = tuiStyle.Base
 	ci.TextStyle = tuiStyle.Base
+	fei := textinput.New()
+	fei.Width = 60
+	fei.PromptStyle = tuiStyle.Base
+	fei.TextStyle = tuiStyle.Base
+	li := textinput.New()
+	li.Placeholder = "npub, hex or name@domain"
+	li.Width = 60
+	li.PromptStyle = tuiStyle.Base
+	li.TextStyle = tuiStyle.Base
+	hi := textinput.New()
+	hi.Placeholder = "hashtag"
+	hi.Width = 40
+	hi.PromptStyle = tuiStyle.Base
+	hi.TextStyle = tuiStyle.Base
+	si := textinput.New()
+	si.Placeholder = "words..."
+	si.Width = 60
+	si.PromptStyle = tuiStyle.Base
+	si.TextStyle = tuiStyle.Base
+	lfi := textinput.New()
+	lfi.Prompt = ""
+	lfi.PromptStyle = tuiStyle.Base
+	lfi.TextStyle = tuiStyle.Base
 	cti := textinput.New()
 	cti.Placeholder = "npub or hex..."
 	cti.Width = 60
@@ -172,20 +320,55 @@ func initialModel() model {
 		likedMap:      make(map[string]string),
 		boostedMap:    make(map[string]string),
 		loading:       false,
-		inbox:         false,
-		notesOnly:     true,
 		relayLines:    nil,
 		relayURLs:     nil,
-		followLines:   nil,
 		setKeyInput:   ti,
 		addRelayInput: ar,
 		followInput:   fi,
+		followEditInput: fei,
+		listsInput:    li,
+		hashtagInput:  hi,
+		searchInput:   si,
+		listFilterInput: lfi,
 		composeInput:  ci,
+		composeArea:   ca,
 		composeToInput: cti,
 	}
 }
 
 func (m model) Init() tea.Cmd {
+	return tea.Batch(backgroundCmd, syncListsCmd, tickCmd())
+}
+
+// tickCmd wakes the TUI up periodically for background work.
+func tickCmd() tea.Cmd {
+	return tea.Tick(defaultScheduleInterval, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+// backgroundCmd sends due scheduled posts and retries due outbox deliveries.
+func backgroundCmd() tea.Msg {
+	var msg backgroundDoneMsg
+	for _, p := range publishDueScheduled() {
+		if p.err != nil {
+			msg.scheduledFailed++
+		} else {
+			msg.scheduledSent++
+		}
+	}
+	flushOutbox(false)
+	msg.outboxPending, msg.outboxFailed = outboxCounts()
+	return msg
+}
+
+// syncListsCmd picks up mute and interest list changes made by other
+// clients.
+func syncListsCmd() tea.Msg {
+	if config.PrivateKey != "" {
+		syncMutes()
+		interestSpec.sync()
+	}
 	return nil
 }
 
@@ -200,7 +383,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.height < 10 {
 			m.height = 10
 		}
+		m.composeArea.SetWidth(m.width - 6)
+		m.composeArea.SetHeight(m.height / 3)
+	case tickMsg:
+		return m, tea.Batch(backgroundCmd, tickCmd())
+	case backgroundDoneMsg:
+		if msg.scheduledSent > 0 {
+			m.flash = fmt.Sprintf("Published %d scheduled post(s)", msg.scheduledSent)
+		}
+		if msg.scheduledFailed > 0 {
+			m.flash = fmt.Sprintf("%d scheduled post(s) failed and stay queued", msg.scheduledFailed)
+		}
+		m.outboxPending = msg.outboxPending
+		m.outboxFailed = msg.outboxFailed
+		return m, nil
+	case publishStatusMsg, publishDoneMsg, publishExpireMsg:
+		return updatePublish(m, msg)
+	case muteDoneMsg:
+		return updateMuteDone(m, msg)
+	case listDoneMsg:
+		return updateListDone(m, msg)
+	case liveStartedMsg, liveEventMsg, liveNamesMsg:
+		return updateLive(m, msg)
+	case homeLoadedMsg:
+		// older pages may arrive while a note is open
+		if msg.older {
+			return appendOlder(m, msg), nil
+		}
 	case tea.KeyMsg:
+		m.flash = ""
 		switch msg.String() {
 		case "ctrl+c", "q":
 			if m.screen == screenMenu || m.screen == screenOptions {
@@ -236,11 +447,46 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return updateImageURLSelect(m, msg)
 	case screenImageASCII:
 		return updateImageASCII(m, msg)
+	case screenDrafts:
+		return updateDrafts(m, msg)
+	case screenPublishLog:
+		return updatePublishLog(m, msg)
+	case screenContactSync:
+		return updateContactSync(m, msg)
+	case screenCandidates:
+		return updateCandidates(m, msg)
+	case screenProfile:
+		return updateProfile(m, msg)
+	case screenEditProfile:
+		return updateEditProfile(m, msg)
+	case screenBookmarks:
+		return updateBookmarks(m, msg)
+	case screenLists:
+		return updatePeopleLists(m, msg)
+	case screenHashtags:
+		return updateHashtags(m, msg)
+	case screenSearch:
+		return updateSearch(m, msg)
 	}
 	return m, nil
 }
 
 func (m model) View() string {
+	v := m.screenView()
+	if panel := viewPublishPanel(m); panel != "" {
+		v += "\n" + tuiStyle.Screen.Render(panel)
+	}
+	if m.flash != "" {
+		v += "\n" + tuiStyle.Screen.Render(tuiStyle.Base.Render("i  "+m.flash))
+	}
+	if m.outboxPending > 0 || m.outboxFailed > 0 {
+		line := fmt.Sprintf("i  outbox: %d pending, %d failed deliveries", m.outboxPending, m.outboxFailed)
+		v += "\n" + tuiStyle.Screen.Render(tuiStyle.Base.Render(line))
+	}
+	return v
+}
+
+func (m model) screenView() string {
 	switch m.screen {
 	case screenMenu:
 		return viewMenu(m)
@@ -268,82 +514,103 @@ func (m model) View() string {
 		return viewImageURLSelect(m)
 	case screenImageASCII:
 		return viewImageASCII(m)
+	case screenDrafts:
+		return viewDrafts(m)
+	case screenPublishLog:
+		return viewPublishLog(m)
+	case screenContactSync:
+		return viewContactSync(m)
+	case screenCandidates:
+		return viewCandidates(m)
+	case screenProfile:
+		return viewProfile(m)
+	case screenEditProfile:
+		return viewEditProfile(m)
+	case screenBookmarks:
+		return viewBookmarks(m)
+	case screenLists:
+		return viewPeopleLists(m)
+	case screenHashtags:
+		return viewHashtags(m)
+	case screenSearch:
+		return viewSearch(m)
 	}
 	return ""
 }
 
-// loadHomeFeed runs in background and sends homeLoadedMsg
-func loadHomeFeed(inbox, notesOnly, aether bool) tea.Msg {
-	var keys []string
+// loadFeed fetches the newest events of f, or when until is set the newest
+// ones before it; it runs in the background.
+func loadFeed(f Feed, until time.Time) homeLoadedMsg {
 	nameMap := make(map[string]string)
-	if !aether {
-		for _, follow := range config.Following {
-			keys = append(keys, follow.Key)
-			if follow.Name != "" {
-				nameMap[follow.Key] = follow.Name
-			}
+	for _, follow := range config.Following {
+		if follow.Name != "" {
+			nameMap[follow.Key] = follow.Name
 		}
 	}
-	if !inbox && !aether && len(keys) == 0 {
-		return homeLoadedMsg{events: nil, nameMap: nameMap, likedMap: make(map[string]string), boostedMap: make(map[string]string), inbox: inbox, aether: false, errMsg: "Follow someone first"}
+	empty := homeLoadedMsg{nameMap: nameMap, likedMap: make(map[string]string), boostedMap: make(map[string]string)}
+	filter, keys, err := f.filter(feedLimit)
+	if err != nil {
+		empty.errMsg = err.Error()
+		return empty
 	}
-	if inbox && config.PrivateKey == "" {
-		return homeLoadedMsg{events: nil, nameMap: nameMap, likedMap: make(map[string]string), boostedMap: make(map[string]string), inbox: inbox, aether: false, errMsg: "Set key first"}
+	if !until.IsZero() {
+		filter.Until = &until
 	}
-	initNostr()
-	var pubkey string
-	if config.PrivateKey != "" {
-		pubkey = getPubKey(config.PrivateKey)
-	}
-	filters := nostr.Filters{{Limit: feedLimit}}
-	if inbox {
-		filters[0].Tags = nostr.TagMap{"p": {pubkey}}
-		filters[0].Kinds = []int{nostr.KindEncryptedDirectMessage}
-	} else if aether {
-		filters[0].Kinds = []int{nostr.KindTextNote}
-	} else {
-		filters[0].Authors = keys
-		filters[0].Kinds = []int{nostr.KindTextNote}
-	}
-	_, all := pool.Sub(filters)
+	authors := make(map[string]bool)
+	for _, key := range keys {
+		authors[key] = true
+	}
+	sub := f.subscribe(filter, keys)
+	defer sub.Close()
 	var events []nostr.Event
-	ch := iterEventsWithTimeout(nostr.Unique(all), 2*time.Second)
-	for ev := range ch {
-		if inbox {
-			events = append(events, ev)
-		} else if aether {
-			hasE := false
-			for _, tag := range ev.Tags {
-				if len(tag) > 0 && tag[0] == "e" {
-					hasE = true
-					break
-				}
-			}
-			if !hasE {
-				events = append(events, ev)
-			}
-		} else if notesOnly {
-			hasE := false
-			for _, tag := range ev.Tags {
-				if len(tag) > 0 && tag[0] == "e" {
-					hasE = true
-					break
-				}
-			}
-			if !hasE {
-				events = append(events, ev)
-			}
-		} else {
-			events = append(events, ev)
+	mutes := currentMuteFilter()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), f.timeout(sub)) {
+		if mutes.muted(ev) || !f.accepts(ev) {
+			continue
+		}
+		if len(keys) > 0 && !authors[ev.PubKey] && !f.interesting(ev) {
+			continue
 		}
-		if len(events) >= feedLimit {
+		events = append(events, ev)
+		if len(keys) == 0 && len(events) >= feedLimit {
 			break
 		}
 	}
+	// results from several relays arrive interleaved: keep the newest
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	if len(events) > feedLimit {
+		events = events[:feedLimit]
+	}
+	cacheEvents(events)
 	// fetch Kind 0 metadata for authors we don't have names for
 	nameMap = fillNameMap(events, nameMap)
 	likedMap, boostedMap := loadOurReactions(events)
-	return homeLoadedMsg{events: events, nameMap: nameMap, likedMap: likedMap, boostedMap: boostedMap, inbox: inbox, aether: aether}
+	return homeLoadedMsg{events: events, nameMap: nameMap, likedMap: likedMap, boostedMap: boostedMap}
+}
+
+func loadFeedCmd(f Feed, seq int) tea.Cmd {
+	return func() tea.Msg {
+		msg := loadFeed(f, time.Time{})
+		msg.seq = seq
+		return msg
+	}
+}
+
+// loadOlderCmd fetches the page of f before until.
+func loadOlderCmd(f Feed, seq int, until time.Time) tea.Cmd {
+	return func() tea.Msg {
+		msg := loadFeed(f, until)
+		msg.seq = seq
+		msg.older = true
+		return msg
+	}
+}
+
+// authorFeed shows the notes of pubkey.
+func authorFeed(pubkey, name string) Feed {
+	return Feed{Name: "Notes by " + name, Authors: []string{pubkey}}
 }
 
 // fillNameMap fetches Kind 0 metadata for authors not in nameMap and returns updated map.
@@ -366,8 +633,10 @@ func fillNameMap(events []nostr.Event, nameMap map[string]string) map[string]str
 		Kinds:   []int{nostr.KindSetMetadata},
 		Limit:   len(authors),
 	}}
-	_, metaCh := pool.Sub(filters)
-	ch := iterEventsWithTimeout(nostr.Unique(metaCh), 2*time.Second)
+	sub := subscribePool(filters)
+	defer sub.Close()
+	claims := make(map[string]string)
+	ch := iterEventsWithTimeout(nostr.Unique(sub.Events), 2*time.Second)
 	for ev := range ch {
 		if ev.Kind != nostr.KindSetMetadata {
 			continue
@@ -379,7 +648,9 @@ func fillNameMap(events []nostr.Event, nameMap map[string]string) map[string]str
 		if meta.Name != "" {
 			nameMap[ev.PubKey] = meta.Name
 		}
+		claims[ev.PubKey] = meta.NIP05
 	}
+	checkNIP05s(claims)
 	return nameMap
 }
 
@@ -403,8 +674,9 @@ func loadOurReactions(events []nostr.Event) (likedMap, boostedMap map[string]str
 		Kinds:   []int{nostr.KindReaction, nostr.KindBoost},
 		Limit:   200,
 	}}
-	_, ch := pool.Sub(filters)
-	for ev := range iterEventsWithTimeout(nostr.Unique(ch), 2*time.Second) {
+	sub := subscribePool(filters)
+	defer sub.Close()
+	for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 2*time.Second) {
 		var targetID string
 		for _, tag := range ev.Tags {
 			if len(tag) > 0 && tag[0] == "e" && len(tag) > 1 {
@@ -424,32 +696,30 @@ func loadOurReactions(events []nostr.Event) (likedMap, boostedMap map[string]str
 	return likedMap, boostedMap
 }
 
-func loadFeedCmd(inbox, notesOnly, aether bool) tea.Cmd {
-	return func() tea.Msg {
-		return loadHomeFeed(inbox, notesOnly, aether)
-	}
-}
-
 // loadReplies fetches Kind 1 events that reference eventID via e-tag (NIP-10).
 func loadReplies(eventID string) tea.Msg {
 	if eventID == "" {
 		return repliesLoadedMsg{replies: nil, nameMap: make(map[string]string), rootID: ""}
 	}
-	initNostr()
 	filters := nostr.Filters{{
 		Tags:  nostr.TagMap{"e": {eventID}},
 		Kinds: []int{nostr.KindTextNote},
 		Limit: 50,
 	}}
-	_, all := pool.Sub(filters)
+	sub := subscribePool(filters)
+	defer sub.Close()
 	var replies []nostr.Event
-	ch := iterEventsWithTimeout(nostr.Unique(all), 2*time.Second)
+	mutes := currentMuteFilter()
+	ch := iterEventsWithTimeout(nostr.Unique(sub.Events), 2*time.Second)
 	for ev := range ch {
-		replies = append(replies, ev)
+		if !mutes.muted(ev) {
+			replies = append(replies, ev)
+		}
 	}
 	sort.Slice(replies, func(i, j int) bool {
 		return replies[i].CreatedAt.Before(replies[j].CreatedAt)
 	})
+	cacheEvents(replies)
 	nameMap := make(map[string]string)
 	for _, ev := range replies {
 		if _, ok := nameMap[ev.PubKey]; !ok {
@@ -466,8 +736,10 @@ func loadReplies(eventID string) tea.Msg {
 			Kinds:   []int{nostr.KindSetMetadata},
 			Limit:   len(authors),
 		}}
-		_, metaCh := pool.Sub(metaFilters)
-		for ev := range iterEventsWithTimeout(nostr.Unique(metaCh), 2*time.Second) {
+		sub := subscribePool(metaFilters)
+		defer sub.Close()
+		claims := make(map[string]string)
+		for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 2*time.Second) {
 			if ev.Kind != nostr.KindSetMetadata {
 				continue
 			}
@@ -478,7 +750,9 @@ func loadReplies(eventID string) tea.Msg {
 			if meta.Name != "" {
 				nameMap[ev.PubKey] = meta.Name
 			}
+			claims[ev.PubKey] = meta.NIP05
 		}
+		checkNIP05s(claims)
 	}
 	return repliesLoadedMsg{replies: replies, nameMap: nameMap, rootID: eventID}
 }
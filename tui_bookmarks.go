@@ -0,0 +1,230 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type bookmarksLoadedMsg struct {
+	list    nip51List
+	notes   map[string]nostr.Event
+	nameMap map[string]string
+	err     error
+}
+
+// listDoneMsg reports an update of one of our NIP-51 lists.
+type listDoneMsg struct {
+	label    string // list name for the publish panel
+	flash    string
+	event    *nostr.Event
+	statuses chan deliveryStatus
+	err      error
+}
+
+func loadBookmarks() tea.Msg {
+	list, err := bookmarkSpec.sync()
+	events := fetchEvents(listEventIDs(list))
+	notes := make(map[string]nostr.Event)
+	for _, ev := range events {
+		notes[ev.ID] = ev
+	}
+	nameMap := make(map[string]string)
+	for _, follow := range config.Following {
+		if follow.Name != "" {
+			nameMap[follow.Key] = follow.Name
+		}
+	}
+	nameMap = fillNameMap(events, nameMap)
+	return bookmarksLoadedMsg{list: list, notes: notes, nameMap: nameMap, err: err}
+}
+
+// bookmarkCmd adds item to the bookmark list or removes it.
+func bookmarkCmd(item listItem, add bool) tea.Cmd {
+	return func() tea.Msg {
+		_, ev, statuses, err := bookmarkSpec.update([]listItem{item}, add)
+		flash := "Bookmarked"
+		if !add {
+			flash = "Removed bookmark"
+		}
+		return listDoneMsg{label: "Bookmarks", flash: flash, event: ev, statuses: statuses, err: err}
+	}
+}
+
+// pinCmd pins our note id to our profile or unpins it.
+func pinCmd(id string, pin bool) tea.Cmd {
+	return func() tea.Msg {
+		ev, statuses, err := pinNote(id, pin)
+		flash := "Pinned to your profile"
+		if !pin {
+			flash = "Unpinned"
+		}
+		return listDoneMsg{label: "Pins", flash: flash, event: ev, statuses: statuses, err: err}
+	}
+}
+
+func updateListDone(m model, msg listDoneMsg) (tea.Model, tea.Cmd) {
+	m.detailStatus = ""
+	if msg.err != nil {
+		m.flash = msg.label + ": " + msg.err.Error()
+		return m, nil
+	}
+	m.flash = msg.flash
+	if m.listsFrom == "" {
+		m = setPeopleLists(m, loadPeopleLists())
+	}
+	m.bookmarks = bookmarkSpec.load().Items
+	if m.bookmarkCur >= len(m.bookmarks) && m.bookmarkCur > 0 {
+		m.bookmarkCur = len(m.bookmarks) - 1
+	}
+	m.hashtags = interestSpec.load().Items
+	if m.hashtagCur >= len(m.hashtags) && m.hashtagCur > 0 {
+		m.hashtagCur = len(m.hashtags) - 1
+	}
+	return watchPublish(m, msg.label, msg.event, msg.statuses)
+}
+
+func openBookmarks(m model) (model, tea.Cmd) {
+	if config.PrivateKey == "" {
+		m.flash = "Set key first (Optionen)"
+		return m, nil
+	}
+	m.screen = screenBookmarks
+	m.bookmarks = bookmarkSpec.load().Items
+	m.bookmarksLoading = true
+	m.bookmarkCur = 0
+	m.err = ""
+	return m, loadBookmarks
+}
+
+func updateBookmarks(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case bookmarksLoadedMsg:
+		m.bookmarksLoading = false
+		m.bookmarks = msg.list.Items
+		m.bookmarkNotes = msg.notes
+		for k, v := range msg.nameMap {
+			if v != "" {
+				m.nameMap[k] = v
+			}
+		}
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		if m.bookmarkCur >= len(m.bookmarks) {
+			m.bookmarkCur = 0
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.bookmarkCur > 0 {
+				m.bookmarkCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.bookmarkCur < len(m.bookmarks)-1 {
+				m.bookmarkCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if m.bookmarkCur >= len(m.bookmarks) {
+				return m, nil
+			}
+			ev, ok := m.bookmarkNotes[m.bookmarks[m.bookmarkCur].Value]
+			if !ok {
+				return m, nil
+			}
+			m.screen = screenDetail
+			m.detailReturn = screenBookmarks
+			m.detailStack = []nostr.Event{ev}
+			m.detailReplies = nil
+			m.detailReplyCur = 0
+			m.detailRepliesLoading = true
+			m.detailStatus = ""
+			return m, loadRepliesCmd(ev.ID)
+		case "x", "d":
+			if m.bookmarkCur >= len(m.bookmarks) {
+				return m, nil
+			}
+			return m, bookmarkCmd(m.bookmarks[m.bookmarkCur], false)
+		case "v":
+			if m.bookmarkCur >= len(m.bookmarks) {
+				return m, nil
+			}
+			item := m.bookmarks[m.bookmarkCur]
+			item.Private = !item.Private
+			return m, bookmarkCmd(item, true)
+		case "p":
+			if m.bookmarkCur >= len(m.bookmarks) {
+				return m, nil
+			}
+			if ev, ok := m.bookmarkNotes[m.bookmarks[m.bookmarkCur].Value]; ok {
+				return openProfile(m, ev.PubKey)
+			}
+			return m, nil
+		case "r":
+			return openBookmarks(m)
+		case "u", "b", "esc":
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func viewBookmarks(m model) string {
+	width := m.width - 30
+	if width < 20 {
+		width = 20
+	}
+	s := tuiStyle.Base.Render("1  Bookmarks") + "\n\n"
+	if m.bookmarksLoading && len(m.bookmarks) == 0 {
+		s += tuiStyle.Base.Render("i  Loading bookmarks...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.err != "" {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
+	}
+	if len(m.bookmarks) == 0 {
+		s += tuiStyle.Base.Render("i  No bookmarks. [B] in a note adds one.") + "\n"
+	}
+
+	avail := m.height - 8
+	if avail < 3 {
+		avail = 3
+	}
+	start := 0
+	if m.bookmarkCur >= avail {
+		start = m.bookmarkCur - avail + 1
+	}
+	for i := start; i < len(m.bookmarks) && i < start+avail; i++ {
+		item := m.bookmarks[i]
+		var line string
+		if ev, ok := m.bookmarkNotes[item.Value]; ok && item.Type == "e" {
+			author := m.nameMap[ev.PubKey]
+			if author == "" {
+				author = shorten(ev.PubKey)
+			}
+			line = "0  [" + author + "] " + draftPreview(ev.Content, width)
+		} else if item.Type == "e" && m.bookmarksLoading {
+			line = "i  note " + shorten(item.Value) + " (loading)"
+		} else if item.Type == "e" {
+			line = "i  note " + shorten(item.Value) + " (not found)"
+		} else {
+			line = "i  " + item.String()
+		}
+		if item.Private {
+			line += "  (private)"
+		}
+		if i == m.bookmarkCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] open  [p] profile  [x] remove  [v] private/public  [r] refresh  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
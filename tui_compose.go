@@ -2,8 +2,12 @@ package main
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"sort"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -51,8 +55,15 @@ func updateFollow(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.followInput.Blur()
 			m.followInput.Reset()
 			m.err = ""
-			m.followLines = buildFollowLines()
 			return m, nil
+		case "ctrl+f":
+			key := translatePubkey(m.followInput.Value())
+			if key == "" {
+				m.err = "Invalid pubkey (use npub or hex)"
+				return m, nil
+			}
+			m.followInput.Blur()
+			return openCandidates(m, key, screenFollow)
 		}
 	}
 	var cmd tea.Cmd
@@ -65,30 +76,140 @@ func viewFollow(m model) string {
 	if m.err != "" {
 		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
 	}
-	s += tuiStyle.Base.Render("i  Enter pubkey (npub or hex) to follow. Esc to cancel.") + "\n\n"
+	s += tuiStyle.Base.Render("i  Enter pubkey (npub or hex) to follow. Esc to cancel.") + "\n"
+	s += tuiStyle.Base.Render("i  Ctrl+F picks from the people they follow instead.") + "\n\n"
 	s += tuiStyle.Base.Render("Pubkey: ") + m.followInput.View() + "\n"
 	return tuiStyle.Screen.Render(s)
 }
 
+// composeCursor returns the cursor position in composeArea as a rune offset
+// into its value.
+func composeCursor(m model) int {
+	lines := strings.Split(m.composeArea.Value(), "\n")
+	row := m.composeArea.Line()
+	pos := 0
+	for i := 0; i < row && i < len(lines); i++ {
+		pos += len([]rune(lines[i])) + 1
+	}
+	info := m.composeArea.LineInfo()
+	return pos + info.StartColumn + info.ColumnOffset
+}
+
+// updateComposeSuggestions recomputes the @mention / #hashtag popup for the
+// token in front of the cursor.
+func updateComposeSuggestions(m model) model {
+	token := completionToken(m.composeArea.Value(), composeCursor(m))
+	m.composeSuggestions = nil
+	if token != "" {
+		if token[0] == '@' {
+			m.composeSuggestions = mentionSuggestions(token[1:], m.nameMap)
+		} else {
+			m.composeSuggestions = hashtagSuggestions(token[1:], m.events)
+		}
+	}
+	if m.composeSuggestCur >= len(m.composeSuggestions) {
+		m.composeSuggestCur = 0
+	}
+	return m
+}
+
+// acceptComposeSuggestion replaces the token in front of the cursor with the
+// selected suggestion.
+func acceptComposeSuggestion(m model) model {
+	if m.composeSuggestCur < 0 || m.composeSuggestCur >= len(m.composeSuggestions) {
+		return m
+	}
+	sug := m.composeSuggestions[m.composeSuggestCur]
+	token := completionToken(m.composeArea.Value(), composeCursor(m))
+	for range []rune(token) {
+		m.composeArea, _ = m.composeArea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m.composeArea.InsertString(sug.insert + " ")
+	m.composeSuggestions = nil
+	m.composeSuggestCur = 0
+	return m
+}
+
+// resetComposeNote clears the composer and any reply target.
+func resetComposeNote(m model) model {
+	m.composeArea.Blur()
+	m.composeArea.Reset()
+	m.composeSuggestions = nil
+	m.composePreview = false
+	m.composeReplyTargetID = ""
+	m.composeReplyTargetAuthor = ""
+	m.composeReplyRootID = ""
+	m.composeReplyRootAuthor = ""
+	m.composeDraftID = ""
+	return m
+}
+
+// openEditorCmd hands the current draft to $EDITOR and reports the result
+// with an editorDoneMsg once the editor exits.
+func openEditorCmd(draft string, help ...string) tea.Cmd {
+	path, err := writeEditorFile(draft, help...)
+	if err != nil {
+		return func() tea.Msg { return editorDoneMsg{err: err} }
+	}
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		if err != nil {
+			os.Remove(path)
+			return editorDoneMsg{err: err}
+		}
+		content, err := readEditorFile(path)
+		return editorDoneMsg{content: content, err: err}
+	})
+}
+
 func updateComposeNote(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && len(m.composeSuggestions) > 0 {
+		switch key.String() {
+		case "up":
+			if m.composeSuggestCur > 0 {
+				m.composeSuggestCur--
+			}
+			return m, nil
+		case "down":
+			if m.composeSuggestCur < len(m.composeSuggestions)-1 {
+				m.composeSuggestCur++
+			}
+			return m, nil
+		case "tab", "enter":
+			return acceptComposeSuggestion(m), nil
+		case "esc":
+			m.composeSuggestions = nil
+			return m, nil
+		}
+	}
 	switch msg := msg.(type) {
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.err = "Editor: " + msg.err.Error()
+			return m, m.composeArea.Focus()
+		}
+		m.composeArea.SetValue(msg.content)
+		m.err = ""
+		return m, m.composeArea.Focus()
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			if m.composeReplyTargetID != "" {
-				m.screen = screenDetail
-				m.composeReplyTargetID = ""
-				m.composeReplyTargetAuthor = ""
-				m.composeReplyRootID = ""
-				m.composeReplyRootAuthor = ""
-			} else {
-				m.screen = screenMenu
-			}
-			m.composeInput.Blur()
+			m = saveComposeDraft(m)
+			m.screen = m.composeReturn
+			m = resetComposeNote(m)
 			m.err = ""
 			return m, nil
-		case "enter":
-			content := m.composeInput.Value()
+		case "ctrl+p":
+			m.composePreview = !m.composePreview
+			return m, nil
+		case "ctrl+e":
+			help := []string{"Write your note above the line."}
+			if m.composeReplyTargetID != "" && len(m.detailStack) > 0 && m.composeReturn == screenDetail {
+				help = []string{"Replying to:", "", m.detailStack[len(m.detailStack)-1].Content}
+			}
+			m.composeArea.Blur()
+			return m, openEditorCmd(m.composeArea.Value(), help...)
+		case "ctrl+s":
+			content := strings.TrimSpace(m.composeArea.Value())
 			if content == "" {
 				return m, nil
 			}
@@ -96,39 +217,68 @@ func updateComposeNote(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = "Set key first"
 				return m, nil
 			}
-			var err error
+			var (
+				published *nostr.Event
+				statuses  chan deliveryStatus
+				err       error
+				label     = "Note"
+			)
 			if m.composeReplyTargetID != "" {
-				err = PublishReply(m.composeReplyRootID, m.composeReplyRootAuthor, m.composeReplyTargetID, m.composeReplyTargetAuthor, content)
+				published, statuses, err = PublishReply(m.composeReplyRootID, m.composeReplyRootAuthor, m.composeReplyTargetID, m.composeReplyTargetAuthor, content)
+				label = "Reply"
 			} else {
-				err = publishNote(content)
+				published, statuses, err = publishNote(content)
 			}
-			m.composeInput.Blur()
-			m.composeInput.Reset()
 			if err != nil {
 				m.err = err.Error()
 				return m, nil
 			}
-			if m.composeReplyTargetID != "" {
-				m.screen = screenDetail
-				targetID := m.composeReplyTargetID
-				m.composeReplyTargetID = ""
-				m.composeReplyTargetAuthor = ""
-				m.composeReplyRootID = ""
-				m.composeReplyRootAuthor = ""
-				m.detailRepliesLoading = true
-				m.err = ""
-				return m, loadRepliesCmd(targetID)
+			if m.composeDraftID != "" {
+				deleteDraft(m.composeDraftID)
 			}
-			m.screen = screenMenu
+			targetID := m.composeReplyTargetID
+			m = resetComposeNote(m)
 			m.err = ""
-			return m, nil
+			m.screen = m.composeReturn
+			if m.screen == screenDrafts {
+				m = openDrafts(m)
+			}
+			var watch tea.Cmd
+			m, watch = watchPublish(m, label, published, statuses)
+			if m.screen == screenDetail && targetID != "" {
+				m.detailRepliesLoading = true
+				return m, tea.Batch(watch, loadRepliesCmd(targetID))
+			}
+			return m, watch
 		}
 	}
+	if m.composePreview {
+		return m, nil
+	}
 	var cmd tea.Cmd
-	m.composeInput, cmd = m.composeInput.Update(msg)
+	m.composeArea, cmd = m.composeArea.Update(msg)
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m = updateComposeSuggestions(m)
+	}
 	return m, cmd
 }
 
+// renderPreview shows content the way readers will see it, with nostr:npub
+// mentions replaced by the names we know.
+func renderPreview(content string, nameMap map[string]string, width int) string {
+	content = mentionRegex.ReplaceAllStringFunc(content, func(ref string) string {
+		key := translatePubkey(strings.TrimPrefix(ref, "nostr:"))
+		if f, ok := config.Following[key]; ok && f.Name != "" {
+			return "@" + f.Name
+		}
+		if n := nameMap[key]; n != "" {
+			return "@" + n
+		}
+		return "@" + shorten(key)
+	})
+	return wrap(content, width)
+}
+
 func viewComposeNote(m model) string {
 	title := "3  Publish note"
 	if m.composeReplyTargetID != "" {
@@ -138,24 +288,51 @@ func viewComposeNote(m model) string {
 	if m.err != "" {
 		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
 	}
-	s += tuiStyle.Base.Render("i  Enter content. Enter to publish, Esc to cancel.") + "\n\n"
-	s += m.composeInput.View() + "\n"
+	if m.composePreview {
+		s += tuiStyle.Base.Render("i  Preview") + "\n\n"
+		width := m.width - 6
+		if width < 20 {
+			width = 20
+		}
+		for _, line := range strings.Split(renderPreview(m.composeArea.Value(), m.nameMap, width), "\n") {
+			s += tuiStyle.Base.Render("  "+line) + "\n"
+		}
+	} else {
+		s += tuiStyle.Base.Render("i  Write your note. @ mention, # hashtag.") + "\n\n"
+		s += m.composeArea.View() + "\n"
+	}
+	if len(m.composeSuggestions) > 0 && !m.composePreview {
+		s += "\n"
+		for i, sug := range m.composeSuggestions {
+			line := "  " + sug.label
+			if i == m.composeSuggestCur {
+				s += tuiStyle.Cursor.Render(line) + "\n"
+			} else {
+				s += tuiStyle.Base.Render(line) + "\n"
+			}
+		}
+		s += tuiStyle.Base.Render("i  [up/down] choose  [tab] insert  [esc] dismiss") + "\n"
+	}
+	count := len([]rune(m.composeArea.Value()))
+	s += "\n" + tuiStyle.Base.Render(fmt.Sprintf("i  %d chars  [ctrl+s] publish  [ctrl+p] preview  [ctrl+e] $EDITOR  [esc] cancel", count)) + "\n"
 	return tuiStyle.Screen.Render(s)
 }
 
-func publishNote(content string) error {
+func publishNote(content string) (*nostr.Event, chan deliveryStatus, error) {
 	if config.PrivateKey == "" {
-		return nil
+		return nil, nil, errors.New("private key not set")
 	}
-	initNostr()
 	ev := nostr.Event{
 		CreatedAt: time.Now(),
 		Kind:      nostr.KindTextNote,
-		Tags:      nil,
+		Tags:      contentTags(nil, content),
 		Content:   content,
 	}
-	_, _, err := pool.PublishEvent(&ev)
-	return err
+	published, statuses, err := publishEvent(&ev)
+	if err == nil {
+		rememberHashtags(content)
+	}
+	return published, statuses, err
 }
 
 func updateComposeMessage(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -163,7 +340,7 @@ func updateComposeMessage(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			m.screen = screenList
+			m.screen = m.composeReturn
 			m.composeToInput.Blur()
 			m.composeInput.Blur()
 			m.composeRecipientSelected = ""
@@ -235,7 +412,7 @@ func updateComposeMessage(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = "Invalid recipient"
 				return m, nil
 			}
-			err := sendEncryptedDM(toKey, content)
+			published, statuses, err := sendEncryptedDM(toKey, content)
 			m.composeInput.Blur()
 			m.composeToInput.Blur()
 			m.composeInput.Reset()
@@ -245,9 +422,9 @@ func updateComposeMessage(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = err.Error()
 				return m, nil
 			}
-			m.screen = screenList
+			m.screen = m.composeReturn
 			m.err = ""
-			return m, nil
+			return watchPublish(m, "Message", published, statuses)
 		}
 	}
 	var cmd tea.Cmd
@@ -301,16 +478,15 @@ func viewComposeMessage(m model) string {
 	return tuiStyle.Screen.Render(s)
 }
 
-func sendEncryptedDM(toPubkey, content string) error {
-	initNostr()
+func sendEncryptedDM(toPubkey, content string) (*nostr.Event, chan deliveryStatus, error) {
 	skHex := hex.EncodeToString([]byte(config.PrivateKey))
 	sharedSecret, err := nip04.ComputeSharedSecret(skHex, toPubkey)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	encrypted, err := nip04.Encrypt(content, sharedSecret)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	ev := nostr.Event{
 		CreatedAt: time.Now(),
@@ -318,10 +494,10 @@ func sendEncryptedDM(toPubkey, content string) error {
 		Tags:      nostr.Tags{{"p", toPubkey}},
 		Content:   encrypted,
 	}
-	_, _, err = pool.PublishEvent(&ev)
+	published, statuses, err := publishEvent(&ev)
 	if err != nil {
 		log.Printf("send DM: %v", err)
-		return err
+		return nil, nil, err
 	}
-	return nil
+	return published, statuses, nil
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type contactsLoadedMsg struct {
+	event   *nostr.Event
+	noReply bool
+	err     string
+}
+
+func loadContactsCmd() tea.Msg {
+	if config.PrivateKey == "" {
+		return contactsLoadedMsg{err: "Set key first"}
+	}
+	ev, err := fetchContactList(getPubKey(config.PrivateKey))
+	return contactsLoadedMsg{event: ev, noReply: err != nil}
+}
+
+func openContactSync(m model) (model, tea.Cmd) {
+	m.screen = screenContactSync
+	m.syncLoading = true
+	m.syncEvent = nil
+	m.syncNoReply = false
+	m.syncRemote = nil
+	m.syncDiff = contactDiff{}
+	m.err = ""
+	return m, loadContactsCmd
+}
+
+func updateContactSync(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case contactsLoadedMsg:
+		m.syncLoading = false
+		m.err = msg.err
+		m.syncEvent = msg.event
+		m.syncNoReply = msg.noReply
+		m.syncRemote = parseContactList(msg.event)
+		m.syncDiff = diffContacts(config.Following, m.syncRemote)
+		return m, nil
+	case tea.KeyMsg:
+		if m.syncLoading {
+			if msg.String() == "u" || msg.String() == "esc" {
+				m.screen = screenFollowing
+				m = refreshFollowing(m)
+			}
+			return m, nil
+		}
+		content := ""
+		if m.syncEvent != nil {
+			content = m.syncEvent.Content
+		}
+		switch msg.String() {
+		case "p":
+			if m.syncEvent == nil {
+				m.err = "No contact list on the relays"
+				return m, nil
+			}
+			pullContacts(m.syncRemote)
+			saveConfig(tuiConfigPath)
+			m.syncDiff = diffContacts(config.Following, m.syncRemote)
+			m.flash = fmt.Sprintf("Now following %d", len(config.Following))
+			return m, nil
+		case "m", "s":
+			if m.syncNoReply {
+				// a newer list may be out there that this would replace
+				m.err = "No relay replied; [r] tries again"
+				return m, nil
+			}
+			if msg.String() == "m" {
+				mergeContacts(m.syncRemote)
+				saveConfig(tuiConfigPath)
+			}
+			if len(config.Following) == 0 {
+				m.err = "Contact list empty"
+				return m, nil
+			}
+			ev, statuses, err := pushContacts(content)
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.syncEvent = ev
+			m.syncRemote = parseContactList(ev)
+			m.syncDiff = diffContacts(config.Following, m.syncRemote)
+			return watchPublish(m, "Contact list", ev, statuses)
+		case "r":
+			return openContactSync(m)
+		case "u", "b", "esc":
+			m.screen = screenFollowing
+			m = refreshFollowing(m)
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func viewContactSync(m model) string {
+	s := tuiStyle.Base.Render("i  Sync contact list") + "\n\n"
+	if m.syncLoading {
+		s += tuiStyle.Base.Render("i  Fetching contact list...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.err != "" {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n\n"
+	}
+	switch {
+	case m.syncNoReply:
+		s += tuiStyle.Base.Render("i  No relay replied: the contact list on the relays is unknown.") + "\n"
+	case m.syncEvent == nil:
+		s += tuiStyle.Base.Render("i  No contact list found on the relays.") + "\n"
+	default:
+		s += tuiStyle.Base.Render(fmt.Sprintf("i  Remote list from %s: %d follows", humanize.Time(m.syncEvent.CreatedAt), len(m.syncRemote))) + "\n"
+	}
+	s += tuiStyle.Base.Render(fmt.Sprintf("i  Both: %d", len(m.syncDiff.Both))) + "\n\n"
+
+	// the remaining lines are shared between both lists
+	avail := m.height - 16
+	if avail < 4 {
+		avail = 4
+	}
+	section := func(title, mark string, keys []string, follows map[string]Follow, max int) string {
+		out := tuiStyle.Base.Render(fmt.Sprintf("1  %s: %d", title, len(keys))) + "\n"
+		for i, key := range keys {
+			if i == max {
+				out += tuiStyle.Base.Render(fmt.Sprintf("i    ... and %d more", len(keys)-max)) + "\n"
+				break
+			}
+			name := shorten(key)
+			if f := follows[key]; f.Name != "" {
+				name = f.Name + " (" + name + ")"
+			}
+			out += tuiStyle.Base.Render("i  "+mark+" "+name) + "\n"
+		}
+		return out
+	}
+	s += section("Local only", "<", m.syncDiff.LocalOnly, config.Following, avail/2)
+	s += section("Remote only", ">", m.syncDiff.RemoteOnly, m.syncRemote, avail/2)
+	s += "\n" + tuiStyle.Base.Render("i  [p] pull  [s] push  [m] merge  [r] refresh  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
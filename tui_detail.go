@@ -14,30 +14,30 @@ import (
 
 func publishLikeCmd(targetID, authorPubkey string) tea.Cmd {
 	return func() tea.Msg {
-		ourID, err := PublishReaction(targetID, authorPubkey)
-		return reactionDoneMsg{err: err, action: "like", targetID: targetID, ourEventID: ourID}
+		ev, statuses, err := PublishReaction(targetID, authorPubkey)
+		return reactionDoneMsg{err: err, action: "like", targetID: targetID, event: ev, statuses: statuses}
 	}
 }
 
 func publishUnlikeCmd(ourReactionID string) tea.Cmd {
 	return func() tea.Msg {
-		err := PublishDeletion(ourReactionID)
-		return reactionDoneMsg{err: err, action: "unlike", ourEventID: ourReactionID}
+		ev, statuses, err := PublishDeletion(ourReactionID)
+		return reactionDoneMsg{err: err, action: "unlike", ourEventID: ourReactionID, event: ev, statuses: statuses}
 	}
 }
 
 func publishBoostCmd(ev nostr.Event) tea.Cmd {
 	return func() tea.Msg {
 		evJSON, _ := json.Marshal(ev)
-		ourID, err := PublishBoost(ev.ID, ev.PubKey, string(evJSON))
-		return reactionDoneMsg{err: err, action: "boost", targetID: ev.ID, ourEventID: ourID}
+		boost, statuses, err := PublishBoost(ev.ID, ev.PubKey, string(evJSON))
+		return reactionDoneMsg{err: err, action: "boost", targetID: ev.ID, event: boost, statuses: statuses}
 	}
 }
 
 func publishUnboostCmd(ourBoostID string) tea.Cmd {
 	return func() tea.Msg {
-		err := PublishDeletion(ourBoostID)
-		return reactionDoneMsg{err: err, action: "unboost", ourEventID: ourBoostID}
+		ev, statuses, err := PublishDeletion(ourBoostID)
+		return reactionDoneMsg{err: err, action: "unboost", ourEventID: ourBoostID, event: ev, statuses: statuses}
 	}
 }
 
@@ -49,6 +49,15 @@ func detailCurrentEvent(m model) *nostr.Event {
 	return &ev
 }
 
+// detailSelectedTag is the hashtag selected with # on the current note.
+func detailSelectedTag(m model) string {
+	ev := detailCurrentEvent(m)
+	if ev == nil || ev.ID != m.detailTagNote {
+		return ""
+	}
+	return m.detailTag
+}
+
 func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	// repliesLoadedMsg must be handled first (can arrive while in detail)
 	switch msg := msg.(type) {
@@ -64,14 +73,20 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.nameMap[k] = v
 			}
 		}
-		return m, nil
+		// keep listening for new replies
+		since := m.detailStack[len(m.detailStack)-1].CreatedAt
+		if len(msg.replies) > 0 {
+			since = msg.replies[len(msg.replies)-1].CreatedAt
+		}
+		return m, liveRepliesCmd(msg.rootID, since)
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "r" && m.inbox && len(m.detailStack) > 0 {
+		if msg.String() == "r" && len(m.detailStack) > 0 && m.detailStack[len(m.detailStack)-1].Kind == nostr.KindEncryptedDirectMessage {
 			ev := m.detailStack[len(m.detailStack)-1]
 			m.screen = screenComposeMessage
+			m.composeReturn = screenList
 			m.composeFollowKeys = nil
 			m.composeRecipientCur = 0
 			m.composeRecipientSelected = ev.PubKey
@@ -87,23 +102,23 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = ""
 			return m, textinput.Blink
 		}
-		if msg.String() == "r" && !m.inbox && len(m.detailStack) > 0 && config.PrivateKey != "" {
+		if msg.String() == "r" && len(m.detailStack) > 0 && config.PrivateKey != "" {
 			ev := m.detailStack[len(m.detailStack)-1]
 			if ev.Kind == nostr.KindTextNote {
 				m.screen = screenComposeNote
+				m = resetComposeNote(m)
+				m.composeReturn = screenDetail
 				m.composeReplyTargetID = ev.ID
 				m.composeReplyTargetAuthor = ev.PubKey
 				m.composeReplyRootID = m.detailStack[0].ID
 				m.composeReplyRootAuthor = m.detailStack[0].PubKey
-				m.composeInput.Reset()
 				authName := shorten(ev.PubKey)
 				if n, ok := m.nameMap[ev.PubKey]; ok && n != "" {
 					authName = n
 				}
-				m.composeInput.Placeholder = "Reply to " + authName + "..."
-				m.composeInput.Focus()
+				m.composeArea.Placeholder = "Reply to " + authName + "..."
 				m.err = ""
-				return m, textinput.Blink
+				return m, m.composeArea.Focus()
 			}
 		}
 	}
@@ -113,6 +128,9 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.detailStatus = "Error: " + msg.err.Error()
 			return m, nil
 		}
+		if msg.event != nil && (msg.action == "like" || msg.action == "boost") {
+			msg.ourEventID = msg.event.ID
+		}
 		switch msg.action {
 		case "like":
 			if msg.targetID != "" && msg.ourEventID != "" {
@@ -147,16 +165,42 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.detailStatus = ""
 		}
-		return m, nil
+		return watchPublish(m, publishActionLabels[msg.action], msg.event, msg.statuses)
 	case tea.KeyMsg:
 		ev := detailCurrentEvent(m)
+		if m.detailMuting {
+			m.detailMuting = false
+			m.detailStatus = ""
+			items := detailMuteItems(m, msg.String())
+			if len(items) == 0 {
+				if msg.String() == "h" || msg.String() == "H" {
+					m.detailStatus = "No hashtags on this note"
+				}
+				return m, nil
+			}
+			m.detailStatus = "Updating mute list..."
+			return m, muteCmd(items, true)
+		}
 		switch msg.String() {
+		case "M":
+			if ev == nil {
+				return m, nil
+			}
+			if config.PrivateKey == "" {
+				m.detailStatus = "Set key first (Optionen)"
+				return m, nil
+			}
+			m.detailMuting = true
+			m.detailStatus = "Mute: [a] author  [t] thread  [h] hashtags  (A/T/H: private)  [esc] cancel"
+			return m, nil
 		case "u", "esc", "q":
 			if len(m.detailStack) <= 1 {
-				m.screen = screenList
+				m.screen = m.detailReturn
 				m.detailStack = nil
 				m.detailReplies = nil
 				m.detailStatus = ""
+				m.detailLive.Close()
+				m.detailLive = nil
 				return m, nil
 			}
 			m.detailStack = m.detailStack[:len(m.detailStack)-1]
@@ -177,7 +221,41 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.detailStatus = ""
 			}
 			return m, nil
+		case "#":
+			if ev == nil {
+				return m, nil
+			}
+			tags := noteHashtags(*ev)
+			if len(tags) == 0 {
+				m.detailStatus = "No hashtags on this note"
+				return m, nil
+			}
+			// cycle through the hashtags, then back to none
+			next := 0
+			for i, tag := range tags {
+				if tag == detailSelectedTag(m) {
+					next = i + 1
+				}
+			}
+			m.detailTagNote = ev.ID
+			m.detailTag = ""
+			m.detailStatus = ""
+			if next < len(tags) {
+				m.detailTag = tags[next]
+				m.detailStatus = "[enter] notes with #" + m.detailTag + "  [#] next hashtag"
+			}
+			return m, nil
 		case "enter", " ":
+			if tag := detailSelectedTag(m); tag != "" {
+				m.detailTag = ""
+				m.detailStatus = ""
+				back := m.detailReturn
+				if back == screenList {
+					// the list is about to show the hashtag
+					back = screenMenu
+				}
+				return openFeed(m, hashtagFeed(tag), back)
+			}
 			if len(m.detailReplies) > 0 && m.detailReplyCur >= 0 && m.detailReplyCur < len(m.detailReplies) {
 				reply := m.detailReplies[m.detailReplyCur]
 				m.detailStack = append(m.detailStack, reply)
@@ -222,6 +300,27 @@ func updateDetail(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, publishUnlikeCmd(ourID)
 			}
 			return m, publishLikeCmd(ev.ID, ev.PubKey)
+		case "p":
+			if ev == nil {
+				return m, nil
+			}
+			return openProfile(m, ev.PubKey)
+		case "B":
+			if ev == nil {
+				return m, nil
+			}
+			if config.PrivateKey == "" {
+				m.detailStatus = "Set key first (Optionen)"
+				return m, nil
+			}
+			m.detailStatus = "Updating bookmarks..."
+			return m, bookmarkCmd(listItem{Type: "e", Value: ev.ID}, !bookmarkSpec.has("e", ev.ID))
+		case "P":
+			if ev == nil || config.PrivateKey == "" || ev.PubKey != getPubKey(config.PrivateKey) {
+				return m, nil
+			}
+			m.detailStatus = "Updating pins..."
+			return m, pinCmd(ev.ID, !pinSpec.has("e", ev.ID))
 		case "i":
 			if ev == nil || !config.AllowImageASCII {
 				return m, nil
@@ -262,12 +361,24 @@ func viewDetail(m model) string {
 		width = 40
 	}
 	s := tuiStyle.Base.Render("0  "+ev.ID) + "\n\n"
-	s += tuiStyle.Base.Render("i  from "+author+"  "+humanize.Time(ev.CreatedAt)) + "\n\n"
+	s += tuiStyle.Base.Render("i  from "+author+nip05Mark(ev.PubKey)+"  "+humanize.Time(ev.CreatedAt)) + "\n\n"
 	content := ev.Content
 	wrapped := wrap(content, width)
 	for _, line := range strings.Split(wrapped, "\n") {
 		s += tuiStyle.Base.Render("  "+line) + "\n"
 	}
+	if tags := noteHashtags(ev); len(tags) > 0 {
+		s += "\n" + tuiStyle.Base.Render("i ")
+		selected := detailSelectedTag(m)
+		for _, tag := range tags {
+			if tag == selected {
+				s += tuiStyle.Base.Render(" ") + tuiStyle.Cursor.Render("#"+tag)
+			} else {
+				s += tuiStyle.Base.Render(" #" + tag)
+			}
+		}
+		s += "\n"
+	}
 
 	// Replies section
 	if m.detailRepliesLoading {
@@ -284,7 +395,7 @@ func viewDetail(m model) string {
 			if len([]rune(preview)) > width-12 {
 				preview = string([]rune(preview)[:width-15]) + "..."
 			}
-			line := "0  [" + replyAuthor + "] " + preview
+			line := "0  [" + replyAuthor + nip05Mark(reply.PubKey) + "] " + preview
 			if i == m.detailReplyCur {
 				s += tuiStyle.Cursor.Render(line) + "\n"
 			} else {
@@ -307,15 +418,27 @@ func viewDetail(m model) string {
 	if boosted {
 		boostStr += "\u2713"
 	}
-	footer := likeStr + "  " + boostStr + "  [c] copy npub"
-	if m.inbox {
-		footer += "  [r] reply"
-	} else if ev.Kind == nostr.KindTextNote {
+	bookmarkStr := "[B] bookmark "
+	if bookmarkSpec.has("e", ev.ID) {
+		bookmarkStr += "\u2713"
+	}
+	footer := likeStr + "  " + boostStr + "  " + bookmarkStr + "  [c] copy npub  [p] profile  [M] mute"
+	if config.PrivateKey != "" && ev.PubKey == getPubKey(config.PrivateKey) {
+		if pinSpec.has("e", ev.ID) {
+			footer += "  [P] pin \u2713"
+		} else {
+			footer += "  [P] pin"
+		}
+	}
+	if ev.Kind == nostr.KindEncryptedDirectMessage || ev.Kind == nostr.KindTextNote {
 		footer += "  [r] reply"
 	}
 	if config.AllowImageASCII && len(extractImageURLs(ev.Content)) > 0 {
 		footer += "  [i] image as ASCII"
 	}
+	if len(noteHashtags(ev)) > 0 {
+		footer += "  [#] hashtags"
+	}
 	footer += "  [u] back"
 	if len(m.detailReplies) > 0 {
 		footer += "  [j/k] replies  [enter] open"
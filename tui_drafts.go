@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveComposeDraft stores the composer content as a draft, or drops the
+// draft being edited when the composer was emptied.
+func saveComposeDraft(m model) model {
+	content := m.composeArea.Value()
+	if strings.TrimSpace(content) == "" {
+		if m.composeDraftID != "" {
+			deleteDraft(m.composeDraftID)
+		}
+		return m
+	}
+	d, err := saveDraft(Draft{
+		ID:            m.composeDraftID,
+		Content:       content,
+		ReplyTo:       m.composeReplyTargetID,
+		ReplyToAuthor: m.composeReplyTargetAuthor,
+		RootID:        m.composeReplyRootID,
+		RootAuthor:    m.composeReplyRootAuthor,
+	})
+	if err != nil {
+		m.flash = "Can't save draft: " + err.Error()
+		return m
+	}
+	m.composeDraftID = d.ID
+	m.flash = "Draft saved"
+	return m
+}
+
+func openDrafts(m model) model {
+	m.screen = screenDrafts
+	m.drafts = loadDrafts()
+	m.scheduled = readSchedule()
+	if m.draftCur >= len(m.drafts)+len(m.scheduled) {
+		m.draftCur = 0
+	}
+	return m
+}
+
+func updateDrafts(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		total := len(m.drafts) + len(m.scheduled)
+		switch msg.String() {
+		case "up", "k":
+			if m.draftCur > 0 {
+				m.draftCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.draftCur < total-1 {
+				m.draftCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if m.draftCur < 0 || m.draftCur >= len(m.drafts) {
+				return m, nil
+			}
+			d := m.drafts[m.draftCur]
+			m = resetComposeNote(m)
+			m.screen = screenComposeNote
+			m.composeReturn = screenDrafts
+			m.composeDraftID = d.ID
+			m.composeReplyTargetID = d.ReplyTo
+			m.composeReplyTargetAuthor = d.ReplyToAuthor
+			m.composeReplyRootID = d.RootID
+			m.composeReplyRootAuthor = d.RootAuthor
+			m.composeArea.Placeholder = "Your note..."
+			m.composeArea.SetValue(d.Content)
+			m.err = ""
+			return m, m.composeArea.Focus()
+		case "x", "d":
+			if m.draftCur < len(m.drafts) {
+				deleteDraft(m.drafts[m.draftCur].ID)
+			} else if m.draftCur < total {
+				cancelScheduled(m.scheduled[m.draftCur-len(m.drafts)].Event.ID)
+			}
+			m = openDrafts(m)
+			if m.draftCur >= len(m.drafts)+len(m.scheduled) && m.draftCur > 0 {
+				m.draftCur--
+			}
+			return m, nil
+		case "u", "b", "esc":
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// draftPreview flattens content to a single line of at most width runes.
+func draftPreview(content string, width int) string {
+	preview := strings.TrimSpace(strings.ReplaceAll(content, "\n", " "))
+	if width > 3 && len([]rune(preview)) > width {
+		preview = string([]rune(preview)[:width-3]) + "..."
+	}
+	return preview
+}
+
+func viewDrafts(m model) string {
+	width := m.width - 26
+	if width < 20 {
+		width = 20
+	}
+	s := tuiStyle.Base.Render("1  Drafts") + "\n\n"
+	if len(m.drafts) == 0 {
+		s += tuiStyle.Base.Render("i  No drafts. Esc in the composer saves one.") + "\n"
+	}
+	for i, d := range m.drafts {
+		line := "0  " + draftPreview(d.Content, width)
+		if d.ReplyTo != "" {
+			line = "0  re " + shorten(d.ReplyTo) + ": " + draftPreview(d.Content, width-12)
+		}
+		if i == m.draftCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+	if len(m.scheduled) > 0 {
+		s += "\n" + tuiStyle.Base.Render("1  Scheduled") + "\n\n"
+		for i, item := range m.scheduled {
+			line := "i  " + item.At.Format("2006-01-02 15:04") + "  " + draftPreview(item.Event.Content, width-4)
+			if len(m.drafts)+i == m.draftCur {
+				s += tuiStyle.Cursor.Render(line) + "\n"
+			} else {
+				s += tuiStyle.Base.Render(line) + "\n"
+			}
+		}
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] nav  [enter] resume  [x] delete  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
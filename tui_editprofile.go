@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type ownMetadataLoadedMsg struct {
+	fields map[string]interface{}
+	event  *nostr.Event
+	err    error
+}
+
+func loadOwnMetadataCmd() tea.Msg {
+	fields, ev, err := fetchMetadata(getPubKey(config.PrivateKey))
+	return ownMetadataLoadedMsg{fields: fields, event: ev, err: err}
+}
+
+// openEditProfile shows the profile editor, one input per metadata field,
+// filled from our current kind-0 once it is loaded.
+func openEditProfile(m model) (model, tea.Cmd) {
+	if config.PrivateKey == "" {
+		m.flash = "Set key first"
+		return m, nil
+	}
+	m.screen = screenEditProfile
+	m.editProfileLoading = true
+	m.editProfileInputs = nil
+	m.editProfileFields = nil
+	m.editProfileFound = false
+	m.editProfileCur = 0
+	m.err = ""
+	return m, loadOwnMetadataCmd
+}
+
+// editProfileChanges returns the fields whose input differs from the loaded
+// value.
+func editProfileChanges(m model) map[string]string {
+	changes := make(map[string]string)
+	for i, field := range metadataFields {
+		if value := m.editProfileInputs[i].Value(); value != m.editProfileInitial[i] {
+			changes[field] = strings.TrimSpace(value)
+		}
+	}
+	return changes
+}
+
+func focusEditProfile(m model, cur int) (model, tea.Cmd) {
+	m.editProfileInputs[m.editProfileCur].Blur()
+	m.editProfileCur = (cur + len(metadataFields)) % len(metadataFields)
+	return m, m.editProfileInputs[m.editProfileCur].Focus()
+}
+
+func updateEditProfile(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ownMetadataLoadedMsg:
+		m.editProfileLoading = false
+		if errors.Is(msg.err, errProfileUnreadable) {
+			m.err = "Can't edit your profile: " + msg.err.Error() + "; 'noscl metadata --force' replaces it"
+			return m, nil
+		}
+		if msg.err != nil {
+			// without the current profile an edit would drop its fields
+			m.err = "Can't fetch your profile: " + msg.err.Error()
+			return m, nil
+		}
+		m.editProfileFields = msg.fields
+		m.editProfileFound = msg.event != nil
+		m.editProfileInputs = make([]textinput.Model, len(metadataFields))
+		m.editProfileInitial = make([]string, len(metadataFields))
+		for i, field := range metadataFields {
+			in := textinput.New()
+			in.Placeholder = field
+			in.Width = 60
+			in.SetValue(metadataValue(msg.fields, field))
+			m.editProfileInputs[i] = in
+			m.editProfileInitial[i] = in.Value()
+		}
+		m.editProfileCur = 0
+		return m, m.editProfileInputs[0].Focus()
+	case tea.KeyMsg:
+		if m.editProfileLoading || m.editProfileInputs == nil {
+			switch msg.String() {
+			case "esc":
+				m.screen = screenOptions
+			case "r":
+				if !m.editProfileLoading {
+					return openEditProfile(m)
+				}
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			m.editProfileInputs[m.editProfileCur].Blur()
+			m.screen = screenOptions
+			return m, nil
+		case "tab", "down", "enter":
+			return focusEditProfile(m, m.editProfileCur+1)
+		case "shift+tab", "up":
+			return focusEditProfile(m, m.editProfileCur-1)
+		case "ctrl+s":
+			changes := editProfileChanges(m)
+			if len(changes) == 0 {
+				m.flash = "Nothing to change"
+				return m, nil
+			}
+			updated := mergeMetadata(m.editProfileFields, changes)
+			ev, statuses, err := publishMetadata(updated)
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.err = ""
+			m.editProfileFields = updated
+			m.editProfileFound = true
+			for i := range metadataFields {
+				m.editProfileInitial[i] = m.editProfileInputs[i].Value()
+			}
+			return watchPublish(m, "Profile", ev, statuses)
+		}
+		var cmd tea.Cmd
+		m.editProfileInputs[m.editProfileCur], cmd = m.editProfileInputs[m.editProfileCur].Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func viewEditProfile(m model) string {
+	s := tuiStyle.Base.Render("i  Edit profile") + "\n\n"
+	if m.editProfileLoading {
+		s += tuiStyle.Base.Render("i  Fetching your profile...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.editProfileInputs == nil {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n\n"
+		s += tuiStyle.Base.Render("i  [r] try again  [esc] back") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if !m.editProfileFound {
+		s += tuiStyle.Base.Render("i  No profile found on the relays, creating a new one.") + "\n\n"
+	}
+	for i, field := range metadataFields {
+		label := field + ":" + strings.Repeat(" ", 9-len(field))
+		if i == m.editProfileCur {
+			s += tuiStyle.Cursor.Render("1  "+label) + m.editProfileInputs[i].View() + "\n"
+		} else {
+			s += tuiStyle.Base.Render("1  "+label) + m.editProfileInputs[i].View() + "\n"
+		}
+	}
+	if m.err != "" {
+		s += "\n" + tuiStyle.Base.Render("i  "+m.err) + "\n"
+	}
+
+	// what ctrl+s would publish; other fields are kept as they are
+	diff := metadataDiff(m.editProfileFields, mergeMetadata(m.editProfileFields, editProfileChanges(m)))
+	if len(diff) > 0 {
+		s += "\n" + tuiStyle.Base.Render("1  Changes") + "\n"
+		for _, line := range diff {
+			s += tuiStyle.Base.Render("i  "+draftPreview(line, m.width-10)) + "\n"
+		}
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [tab] next field  [ctrl+s] publish  [esc] back  (empty removes a field)") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+type followActivityMsg struct {
+	activity map[string]time.Time
+}
+
+// loadFollowActivityCmd looks up when each followed author last posted a note.
+func loadFollowActivityCmd() tea.Msg {
+	keys := make([]string, 0, len(config.Following))
+	for key := range config.Following {
+		keys = append(keys, key)
+	}
+	activity := make(map[string]time.Time)
+	for key, ev := range fetchNewest(keys, nostr.KindTextNote) {
+		activity[key] = ev.CreatedAt
+	}
+	return followActivityMsg{activity: activity}
+}
+
+func followName(f Follow) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return shorten(f.Key)
+}
+
+// refreshFollowing rebuilds the sorted key list of the Following screen.
+func refreshFollowing(m model) model {
+	keys := make([]string, 0, len(config.Following))
+	for key := range config.Following {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m.followSortActivity {
+			a, b := m.followActivity[keys[i]], m.followActivity[keys[j]]
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+		}
+		a := strings.ToLower(followName(config.Following[keys[i]]))
+		b := strings.ToLower(followName(config.Following[keys[j]]))
+		if a != b {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	m.followKeys = keys
+	if m.followCur >= len(keys) {
+		m.followCur = len(keys) - 1
+	}
+	if m.followCur < 0 {
+		m.followCur = 0
+	}
+	return m
+}
+
+func updateFollowing(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case followActivityMsg:
+		m.followActivity = msg.activity
+		return refreshFollowing(m), nil
+	case tea.KeyMsg:
+		if m.followEdit != "" {
+			return updateFollowEdit(m, msg)
+		}
+		var current Follow
+		if m.followCur < len(m.followKeys) {
+			current = config.Following[m.followKeys[m.followCur]]
+		}
+		if msg.String() != "x" {
+			m.followConfirm = ""
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.followCur > 0 {
+				m.followCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.followCur < len(m.followKeys)-1 {
+				m.followCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if current.Key == "" {
+				return m, nil
+			}
+			return openProfile(m, current.Key)
+		case "o":
+			if current.Key == "" {
+				return m, nil
+			}
+			return openFeed(m, authorFeed(current.Key, followName(current)), screenFollowing)
+		case "x":
+			if current.Key == "" {
+				return m, nil
+			}
+			if m.followConfirm != current.Key {
+				m.followConfirm = current.Key
+				m.flash = "Press x again to unfollow " + followName(current)
+				return m, nil
+			}
+			delete(config.Following, current.Key)
+			saveConfig(tuiConfigPath)
+			m.followConfirm = ""
+			m.flash = "Unfollowed " + followName(current)
+			return refreshFollowing(m), nil
+		case "n", "e", "l":
+			if current.Key == "" {
+				return m, nil
+			}
+			if msg.String() == "l" && config.PrivateKey == "" {
+				m.flash = "Set key first (Optionen)"
+				return m, nil
+			}
+			m.followEditInput.Reset()
+			if msg.String() == "n" {
+				m.followEdit = "name"
+				m.followEditInput.Placeholder = "petname"
+				m.followEditInput.SetValue(current.Name)
+			} else if msg.String() == "l" {
+				m.followEdit = "lists"
+				m.followEditInput.Placeholder = "team news (space separated)"
+				m.followEditInput.SetValue(strings.Join(listsWith(current.Key), " "))
+			} else {
+				m.followEdit = "relays"
+				m.followEditInput.Placeholder = "wss://... (space separated)"
+				m.followEditInput.SetValue(strings.Join(current.Relays, " "))
+			}
+			m.followEditInput.CursorEnd()
+			return m, m.followEditInput.Focus()
+		case "c":
+			if current.Key == "" {
+				return m, nil
+			}
+			if npub, err := nip19.EncodePublicKey(current.Key, ""); err == nil {
+				_ = clipboard.WriteAll(npub)
+				m.flash = "npub copied"
+			}
+			return m, nil
+		case "m":
+			if current.Key == "" {
+				return m, nil
+			}
+			m.screen = screenComposeMessage
+			m.composeReturn = screenFollowing
+			m.composeFollowKeys = nil
+			m.composeRecipientCur = 0
+			m.composeRecipientSelected = current.Key
+			m.composeToInput.Reset()
+			m.composeInput.Reset()
+			m.composeToInput.Blur()
+			m.composeInput.Placeholder = "Message to " + followName(current) + "..."
+			m.err = ""
+			return m, m.composeInput.Focus()
+		case "s":
+			m.followSortActivity = !m.followSortActivity
+			if m.followSortActivity && m.followActivity == nil {
+				m.flash = "Looking up last activity..."
+				return refreshFollowing(m), loadFollowActivityCmd
+			}
+			return refreshFollowing(m), nil
+		case "y":
+			return openContactSync(m)
+		case "g":
+			return openCandidates(m, "", screenFollowing)
+		case "u", "b", "esc":
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updateFollowEdit handles the petname / relay hints input of the Following screen.
+func updateFollowEdit(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.followEdit = ""
+		m.followEditInput.Blur()
+		return m, nil
+	case "enter":
+		if m.followEdit == "lists" && m.followCur < len(m.followKeys) {
+			key := m.followKeys[m.followCur]
+			m.followEdit = ""
+			m.followEditInput.Blur()
+			return m, editPersonLists(key, m.followEditInput.Value())
+		}
+		if m.followCur < len(m.followKeys) {
+			f := config.Following[m.followKeys[m.followCur]]
+			value := strings.TrimSpace(m.followEditInput.Value())
+			if m.followEdit == "name" {
+				f.Name = value
+			} else {
+				f.Relays = nil
+				for _, url := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+					f.Relays = append(f.Relays, nostr.NormalizeURL(url))
+				}
+			}
+			config.Following[f.Key] = f
+			saveConfig(tuiConfigPath)
+		}
+		m.followEdit = ""
+		m.followEditInput.Blur()
+		return refreshFollowing(m), nil
+	}
+	var cmd tea.Cmd
+	m.followEditInput, cmd = m.followEditInput.Update(msg)
+	return m, cmd
+}
+
+// editPersonLists puts pubkey on exactly the lists named in value.
+func editPersonLists(pubkey, value string) tea.Cmd {
+	current := make(map[string]bool)
+	for _, name := range listsWith(pubkey) {
+		current[name] = true
+	}
+	wanted := make(map[string]bool)
+	var add, remove []string
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if !wanted[name] && !current[name] {
+			add = append(add, name)
+		}
+		wanted[name] = true
+	}
+	for name := range current {
+		if !wanted[name] {
+			remove = append(remove, name)
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	return setPersonListsCmd(pubkey, add, remove)
+}
+
+func viewFollowing(m model) string {
+	title := "i  Following (" + humanize.Comma(int64(len(m.followKeys))) + ", by name)"
+	if m.followSortActivity {
+		title = "i  Following (" + humanize.Comma(int64(len(m.followKeys))) + ", by last note)"
+	}
+	s := tuiStyle.Base.Render(title) + "\n\n"
+	if len(m.followKeys) == 0 {
+		s += tuiStyle.Base.Render("i  Not following anyone.") + "\n"
+	}
+
+	avail := m.height - 12
+	if avail < 5 {
+		avail = 5
+	}
+	start := 0
+	if m.followCur >= avail {
+		start = m.followCur - avail + 1
+	}
+	for i := start; i < len(m.followKeys) && i < start+avail; i++ {
+		f := config.Following[m.followKeys[i]]
+		line := "1  " + followName(f)
+		if f.Name != "" {
+			line += " (" + shorten(f.Key) + ")"
+		}
+		if len(f.Relays) > 0 {
+			line += "  " + strings.Join(f.Relays, " ")
+		}
+		if lists := listsWith(f.Key); len(lists) > 0 {
+			line += "  [" + strings.Join(lists, " ") + "]"
+		}
+		if m.followSortActivity {
+			if t, ok := m.followActivity[f.Key]; ok {
+				line += "  " + humanize.Time(t)
+			} else if m.followActivity != nil {
+				line += "  no recent notes"
+			}
+		}
+		if i == m.followCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	if m.followEdit != "" {
+		label := "Petname: "
+		if m.followEdit == "relays" {
+			label = "Relays: "
+		} else if m.followEdit == "lists" {
+			label = "Lists: "
+		}
+		s += "\n" + tuiStyle.Base.Render(label) + m.followEditInput.View() + "\n"
+		s += tuiStyle.Base.Render("i  [enter] save  [esc] cancel") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] profile  [o] notes  [m] message  [n] rename  [e] relays  [l] lists  [c] copy npub  [x] unfollow") + "\n"
+	s += tuiStyle.Base.Render("i  [s] sort  [y] sync with relays  [g] suggestions  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
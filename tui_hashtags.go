@@ -0,0 +1,162 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type hashtagsLoadedMsg struct {
+	list nip51List
+	err  error
+}
+
+func loadHashtags() tea.Msg {
+	list, err := interestSpec.sync()
+	return hashtagsLoadedMsg{list: list, err: err}
+}
+
+// hashtagCmd follows tag or unfollows it.
+func hashtagCmd(tag string, follow bool) tea.Cmd {
+	return func() tea.Msg {
+		ev, statuses, err := followHashtag(tag, follow, false)
+		flash := "Following #" + tag
+		if !follow {
+			flash = "Unfollowed #" + tag
+		}
+		return listDoneMsg{label: "Hashtags", flash: flash, event: ev, statuses: statuses, err: err}
+	}
+}
+
+func openHashtags(m model) (model, tea.Cmd) {
+	m.screen = screenHashtags
+	m.hashtags = interestSpec.load().Items
+	m.hashtagInputActive = false
+	m.err = ""
+	if config.PrivateKey == "" {
+		return m, nil
+	}
+	m.hashtagsLoading = true
+	return m, loadHashtags
+}
+
+func updateHashtags(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case hashtagsLoadedMsg:
+		m.hashtagsLoading = false
+		m.hashtags = msg.list.Items
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		if m.hashtagCur >= len(m.hashtags) {
+			m.hashtagCur = 0
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.hashtagInputActive {
+			return updateHashtagInput(m, msg)
+		}
+		tag := ""
+		if m.hashtagCur < len(m.hashtags) {
+			tag = m.hashtags[m.hashtagCur].Value
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.hashtagCur > 0 {
+				m.hashtagCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.hashtagCur < len(m.hashtags)-1 {
+				m.hashtagCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if tag == "" {
+				return m, nil
+			}
+			return openFeed(m, hashtagFeed(tag), screenHashtags)
+		case "o", "#":
+			m.hashtagInput.Reset()
+			m.hashtagInputActive = true
+			return m, m.hashtagInput.Focus()
+		case "x", "d":
+			if tag == "" {
+				return m, nil
+			}
+			return m, hashtagCmd(tag, false)
+		case "r":
+			return openHashtags(m)
+		case "u", "b", "esc":
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updateHashtagInput reads a hashtag whose notes to show.
+func updateHashtagInput(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.hashtagInputActive = false
+		m.hashtagInput.Blur()
+		return m, nil
+	case "enter":
+		tag := normalizeHashtag(m.hashtagInput.Value())
+		m.hashtagInputActive = false
+		m.hashtagInput.Blur()
+		if tag == "" {
+			return m, nil
+		}
+		if err := validHashtag(tag); err != nil {
+			m.flash = tag + ": " + err.Error()
+			return m, nil
+		}
+		return openFeed(m, hashtagFeed(tag), screenHashtags)
+	}
+	var cmd tea.Cmd
+	m.hashtagInput, cmd = m.hashtagInput.Update(msg)
+	return m, cmd
+}
+
+func viewHashtags(m model) string {
+	s := tuiStyle.Base.Render("1  Hashtags") + "\n\n"
+	if m.err != "" {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
+	}
+	if m.hashtagsLoading && len(m.hashtags) == 0 {
+		s += tuiStyle.Base.Render("i  Loading hashtags...") + "\n"
+	} else if len(m.hashtags) == 0 {
+		s += tuiStyle.Base.Render("i  No followed hashtags. [o] shows one, [f] in its feed follows it.") + "\n"
+	}
+
+	avail := m.height - 10
+	if avail < 3 {
+		avail = 3
+	}
+	start := 0
+	if m.hashtagCur >= avail {
+		start = m.hashtagCur - avail + 1
+	}
+	for i := start; i < len(m.hashtags) && i < start+avail; i++ {
+		item := m.hashtags[i]
+		line := "1  #" + item.Value
+		if item.Private {
+			line += "  (private)"
+		}
+		if i == m.hashtagCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	if m.hashtagInputActive {
+		s += "\n" + tuiStyle.Base.Render("Hashtag: #") + m.hashtagInput.View() + "\n"
+		s += tuiStyle.Base.Render("i  [enter] show notes  [esc] cancel") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] notes  [o] other hashtag  [x] unfollow  [r] refresh  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
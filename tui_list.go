@@ -1,45 +1,225 @@
 package main
 
 import (
+	"fmt"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/charmbracelet/lipgloss"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/nbd-wtf/go-nostr"
 )
 
+// openFeed shows f in the list; back leads to the back screen.
+func openFeed(m model, f Feed, back screen) (model, tea.Cmd) {
+	m.screen = screenList
+	m.loading = true
+	m.events = nil
+	m.err = ""
+	if f.Name != m.feed.Name {
+		m.listFilter = ""
+		m.listFilterActive = false
+	}
+	m.feed = f
+	m.feedReturn = back
+	m.feedSeq++
+	m.feedOlder = false
+	m.feedEnd = false
+	m = stopLiveFeed(m)
+	return m, loadFeedCmd(f, m.feedSeq)
+}
+
+// visibleEvents are the loaded events that match the list filter; listCur
+// and listOffset index into them.
+func visibleEvents(m model) []nostr.Event {
+	if m.listFilter == "" {
+		return m.events
+	}
+	var events []nostr.Event
+	for _, ev := range m.events {
+		if listMatches(ev, m.nameMap, m.listFilter) {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// listMatches tells whether the content, author name or hashtags of ev
+// contain query. A query starting with # only looks at hashtags.
+func listMatches(ev nostr.Event, nameMap map[string]string, query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	if tag := strings.TrimPrefix(query, "#"); tag != query {
+		for _, t := range noteHashtags(ev) {
+			if strings.HasPrefix(t, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.Contains(strings.ToLower(ev.Content), query) {
+		return true
+	}
+	if strings.Contains(strings.ToLower(nameMap[ev.PubKey]), query) {
+		return true
+	}
+	for _, t := range noteHashtags(ev) {
+		if strings.Contains(t, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// setListFilter narrows the list to query, keeping the cursor on the same
+// note while it is still shown.
+func setListFilter(m model, query string) model {
+	current := ""
+	if events := visibleEvents(m); m.listCur >= 0 && m.listCur < len(events) {
+		current = events[m.listCur].ID
+	}
+	m.listFilter = query
+	m.listCur = 0
+	for i, ev := range visibleEvents(m) {
+		if ev.ID == current {
+			m.listCur = i
+			break
+		}
+	}
+	m.listOffset = clampListOffset(m)
+	return m
+}
+
+// updateListFilter edits the filter as it is typed.
+func updateListFilter(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.listFilterActive = false
+		m.listFilterInput.Blur()
+		return setListFilter(m, ""), nil
+	case "enter":
+		m.listFilterActive = false
+		m.listFilterInput.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.listFilterInput, cmd = m.listFilterInput.Update(msg)
+	return setListFilter(m, strings.TrimSpace(m.listFilterInput.Value())), cmd
+}
+
+// loadOlder fetches the page before the oldest loaded event once the cursor
+// nears the end of the list.
+func loadOlder(m model) (model, tea.Cmd) {
+	if m.loading || m.feedOlder || m.feedEnd || len(m.events) == 0 {
+		return m, nil
+	}
+	if m.listCur < len(visibleEvents(m))-3 {
+		return m, nil
+	}
+	m.feedOlder = true
+	until := m.events[len(m.events)-1].CreatedAt
+	return m, loadOlderCmd(m.feed, m.feedSeq, until)
+}
+
+// appendOlder adds a page of older events to the list, leaving out those
+// already shown. A page with nothing new ends the feed.
+func appendOlder(m model, msg homeLoadedMsg) model {
+	if msg.seq != m.feedSeq {
+		return m
+	}
+	m.feedOlder = false
+	if msg.errMsg != "" {
+		m.flash = msg.errMsg
+		return m
+	}
+	have := make(map[string]bool, len(m.events))
+	for _, ev := range m.events {
+		have[ev.ID] = true
+	}
+	added := 0
+	for _, ev := range msg.events {
+		if !have[ev.ID] {
+			have[ev.ID] = true
+			m.events = append(m.events, ev)
+			added++
+		}
+	}
+	if added == 0 {
+		m.feedEnd = true
+		return m
+	}
+	for k, v := range msg.nameMap {
+		if v != "" {
+			m.nameMap[k] = v
+		}
+	}
+	for k, v := range msg.likedMap {
+		m.likedMap[k] = v
+	}
+	for k, v := range msg.boostedMap {
+		m.boostedMap[k] = v
+	}
+	return m
+}
+
+// nextFeed is the configured feed after the current one.
+func nextFeed(m model) (Feed, bool) {
+	if len(config.Feeds) == 0 {
+		return Feed{}, false
+	}
+	for i, f := range config.Feeds {
+		if f.Name == m.feed.Name {
+			return config.Feeds[(i+1)%len(config.Feeds)], true
+		}
+	}
+	return config.Feeds[0], true
+}
+
 func updateList(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case homeLoadedMsg:
+		if msg.seq != m.feedSeq {
+			return m, nil
+		}
 		m.events = msg.events
 		m.nameMap = msg.nameMap
 		m.likedMap = msg.likedMap
 		m.boostedMap = msg.boostedMap
-		m.inbox = msg.inbox
-		m.aether = msg.aether
 		m.loading = false
 		m.listCur = 0
 		m.listOffset = 0
 		if msg.errMsg != "" {
 			m.err = msg.errMsg
+			return m, nil
 		} else if len(m.events) == 0 {
 			m.err = "No events"
 		} else {
 			m.err = ""
 		}
-		return m, nil
+		// keep listening for newer notes
+		since := time.Now()
+		if len(m.events) > 0 {
+			since = m.events[0].CreatedAt
+		}
+		return m, liveFeedCmd(m.feed, m.feedSeq, since)
 	case tea.KeyMsg:
+		if m.listFilterActive {
+			return updateListFilter(m, msg)
+		}
 		if m.loading {
 			// allow backing out while loading, but ignore other keys
 			switch msg.String() {
 			case "u", "b", "esc":
-				m.screen = screenMenu
-				m.events = nil
-				m.err = ""
+				m = leaveList(m)
 				m.loading = false
 				return m, nil
 			}
 			return m, nil
 		}
+		events := visibleEvents(m)
 		switch msg.String() {
 		case "up", "k":
 			m.listCur--
@@ -50,15 +230,38 @@ func updateList(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "down", "j":
 			m.listCur++
-			if m.listCur >= len(m.events) {
-				m.listCur = len(m.events) - 1
+			if m.listCur >= len(events) {
+				m.listCur = len(events) - 1
 			}
 			m.listOffset = clampListOffset(m)
-			return m, nil
+			return loadOlder(m)
+		case "/":
+			m.listFilterInput.SetValue(m.listFilter)
+			m.listFilterInput.CursorEnd()
+			m.listFilterActive = true
+			return m, m.listFilterInput.Focus()
+		case "n", "N":
+			// cycle through the matches
+			if m.listFilter == "" || len(events) == 0 {
+				return m, nil
+			}
+			if msg.String() == "n" {
+				m.listCur = (m.listCur + 1) % len(events)
+			} else {
+				m.listCur = (m.listCur - 1 + len(events)) % len(events)
+			}
+			m.listOffset = clampListOffset(m)
+			return loadOlder(m)
+		case "esc":
+			if m.listFilter != "" {
+				return setListFilter(m, ""), nil
+			}
+			return leaveList(m), nil
 		case "enter", " ":
-			if len(m.events) > 0 && m.listCur >= 0 && m.listCur < len(m.events) {
-				ev := m.events[m.listCur]
+			if len(events) > 0 && m.listCur >= 0 && m.listCur < len(events) {
+				ev := events[m.listCur]
 				m.screen = screenDetail
+				m.detailReturn = screenList
 				m.detailStack = []nostr.Event{ev}
 				m.detailReplies = nil
 				m.detailReplyCur = 0
@@ -67,19 +270,19 @@ func updateList(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, loadRepliesCmd(ev.ID)
 			}
 			return m, nil
+		case ".":
+			return showNewNotes(m)
 		case "r":
-			m.loading = true
-			m.events = nil
-			return m, loadFeedCmd(m.inbox, m.notesOnly, m.aether)
+			return openFeed(m, m.feed, m.feedReturn)
 		case "tab":
-			m.loading = true
-			m.events = nil
-			m.inbox = !m.inbox
-			m.aether = false
-			return m, loadFeedCmd(m.inbox, m.notesOnly, m.aether)
+			if f, ok := nextFeed(m); ok {
+				return openFeed(m, f, screenMenu)
+			}
+			return m, nil
 		case "m":
-			if m.inbox {
+			if m.feed.directMessages() {
 				m.screen = screenComposeMessage
+				m.composeReturn = screenList
 				m.composeFollowKeys = buildComposeFollowKeys()
 				m.composeRecipientCur = 0
 				m.composeRecipientSelected = ""
@@ -91,16 +294,47 @@ func updateList(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			return m, nil
-		case "u", "b", "esc":
-			m.screen = screenMenu
-			m.events = nil
-			m.err = ""
-			return m, nil
+		case "f":
+			tag := m.feed.hashtag()
+			if tag == "" {
+				return m, nil
+			}
+			if config.PrivateKey == "" {
+				m.flash = "Set key first (Optionen)"
+				return m, nil
+			}
+			return m, hashtagCmd(tag, !interestSpec.has("t", tag))
+		case "u", "b":
+			return leaveList(m), nil
 		}
 	}
 	return m, nil
 }
 
+// leaveList goes back to where the list was opened from.
+func leaveList(m model) model {
+	m.screen = m.feedReturn
+	if m.screen == screenFollowing {
+		m = refreshFollowing(m)
+	}
+	m.feedSeq++
+	m.events = nil
+	m.err = ""
+	return stopLiveFeed(m)
+}
+
+// hashtagFollowHint is the footer entry for following a hashtag feed.
+func hashtagFollowHint(f Feed) string {
+	tag := f.hashtag()
+	if tag == "" {
+		return ""
+	}
+	if interestSpec.has("t", tag) {
+		return "  [f] follow #" + tag + " \u2713"
+	}
+	return "  [f] follow #" + tag
+}
+
 func clampListOffset(m model) int {
 	linesPerItem := 3
 	contentLines := m.height - 4
@@ -111,7 +345,7 @@ func clampListOffset(m model) int {
 	if visibleItems < 1 {
 		visibleItems = 1
 	}
-	n := len(m.events)
+	n := len(visibleEvents(m))
 	if n == 0 {
 		return 0
 	}
@@ -135,28 +369,57 @@ func clampListOffset(m model) int {
 }
 
 func viewList(m model) string {
-	title := "1  Home"
-	if m.inbox {
-		title = "1  Inbox"
-	} else if m.aether {
-		title = "1  Aether"
-	} else if !m.notesOnly {
-		title = "1  Notes + Comments"
+	events := visibleEvents(m)
+	title := "1  " + m.feed.Name
+	if m.listFilterActive {
+		s := tuiStyle.Base.Render(title+"  /") + m.listFilterInput.View()
+		s += tuiStyle.Base.Render(fmt.Sprintf("  (%d of %d)", len(events), len(m.events))) + "\n\n"
+		return tuiStyle.Screen.Render(s + viewListEvents(m, events))
+	}
+	if m.listFilter != "" {
+		title += fmt.Sprintf("  /%s (%d of %d)", m.listFilter, len(events), len(m.events))
+	}
+	if m.feedOlder {
+		title += "  (loading older notes...)"
 	}
 	s := tuiStyle.Base.Render(title) + "\n\n"
+	if n := len(m.feedNew); n == 1 {
+		s += tuiStyle.Base.Render("i  \u2191 1 new note  [.] show") + "\n"
+	} else if n > 1 {
+		s += tuiStyle.Base.Render(fmt.Sprintf("i  \u2191 %d new notes  [.] show", n)) + "\n"
+	}
 	if m.loading {
 		s += tuiStyle.Base.Render("i  Loading...") + "\n"
 		return tuiStyle.Screen.Render(s)
 	}
 	if m.err != "" {
 		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
-		footer := "i  [u] back to menu"
-		if m.inbox {
+		footer := "i  [r] refresh  [tab] next feed  [u] back"
+		if m.feed.directMessages() {
 			footer += "  [m] new message"
 		}
+		footer += hashtagFollowHint(m.feed)
 		s += tuiStyle.Base.Render(footer) + "\n"
 		return tuiStyle.Screen.Render(s)
 	}
+	s += viewListEvents(m, events)
+	footer := "i  [j/k] nav  [enter] open  [/] filter  [r] refresh  [tab] next feed  [u] back"
+	if m.listFilter != "" {
+		footer = "i  [j/k] nav  [n/N] next/previous match  [enter] open  [/] filter  [esc] clear filter  [u] back"
+	}
+	if m.feed.directMessages() {
+		footer += "  [m] new message"
+	}
+	footer += hashtagFollowHint(m.feed)
+	s += tuiStyle.Base.Render(footer) + "\n"
+	return tuiStyle.Screen.Render(s)
+}
+
+// viewListEvents renders the visible part of events, the filtered list.
+func viewListEvents(m model, events []nostr.Event) string {
+	if len(events) == 0 && m.listFilter != "" {
+		return tuiStyle.Base.Render("i  No matches.") + "\n"
+	}
 	contentWidth := m.width - 4
 	if contentWidth < 40 {
 		contentWidth = 40
@@ -173,37 +436,33 @@ func viewList(m model) string {
 	}
 	start := m.listOffset
 	end := start + visibleItems
-	if end > len(m.events) {
-		end = len(m.events)
+	if end > len(events) {
+		end = len(events)
 	}
+	s := ""
 	for i := start; i < end; i++ {
-		ev := m.events[i]
-		lines := listLinesForEvent(ev, m.nameMap, contentWidth)
-		for _, line := range lines {
-			if i == m.listCur {
-				s += tuiStyle.Cursor.Render(line) + "\n"
-			} else {
-				s += tuiStyle.Base.Render(line) + "\n"
-			}
+		style := tuiStyle.Base
+		if i == m.listCur {
+			style = tuiStyle.Cursor
+		}
+		for _, line := range listLinesForEvent(events[i], m.nameMap, contentWidth, style, m.listFilter) {
+			s += line + "\n"
 		}
-		if i < len(m.events)-1 {
+		if i < len(events)-1 {
 			s += tuiStyle.Base.Render("i  " + strings.Repeat("\u2500", 22)) + "\n"
 		}
 	}
-	footer := "i  [j/k] nav  [enter] open  [r] refresh  [tab] feed  [u] back"
-	if m.inbox {
-		footer += "  [m] new message"
-	}
-	s += tuiStyle.Base.Render(footer) + "\n"
-	return tuiStyle.Screen.Render(s)
+	return s
 }
 
 // listLinesForEvent returns 1–2 lines for the list: Gopher type 0, author, content preview.
-func listLinesForEvent(ev nostr.Event, nameMap map[string]string, contentWidth int) []string {
+// They are rendered in style, with the parts matching filter highlighted.
+func listLinesForEvent(ev nostr.Event, nameMap map[string]string, contentWidth int, style lipgloss.Style, filter string) []string {
 	author := shorten(ev.PubKey)
 	if n, ok := nameMap[ev.PubKey]; ok && n != "" {
 		author = n
 	}
+	author += nip05Mark(ev.PubKey)
 	content := strings.ReplaceAll(ev.Content, "\n", " ")
 	content = strings.ReplaceAll(content, "\t", " ")
 	content = strings.TrimSpace(content)
@@ -224,5 +483,43 @@ func listLinesForEvent(ev nostr.Event, nameMap map[string]string, contentWidth i
 		last := len(out) - 1
 		out[last] = out[last] + "..."
 	}
+	for i, line := range out {
+		out[i] = renderHighlighted(line, style, filter)
+	}
 	return out
 }
+
+// renderHighlighted renders line in style and the parts of it matching
+// query, ignoring case, in tuiStyle.Match.
+func renderHighlighted(line string, style lipgloss.Style, query string) string {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return style.Render(line)
+	}
+	runes := []rune(line)
+	var b strings.Builder
+	last := 0
+	for i := 0; i+len(q) <= len(runes); {
+		match := true
+		for j, r := range q {
+			if unicode.ToLower(runes[i+j]) != r {
+				match = false
+				break
+			}
+		}
+		if !match {
+			i++
+			continue
+		}
+		if i > last {
+			b.WriteString(style.Render(string(runes[last:i])))
+		}
+		b.WriteString(tuiStyle.Match.Render(string(runes[i : i+len(q)])))
+		i += len(q)
+		last = i
+	}
+	if last < len(runes) {
+		b.WriteString(style.Render(string(runes[last:])))
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+func TestListMatches(t *testing.T) {
+	ev := nostr.Event{
+		PubKey:  "alice",
+		Content: "Trying out #Golang on a Sunday",
+		Tags:    nostr.Tags{{"t", "Nostr"}},
+	}
+	nameMap := map[string]string{"alice": "Alice Liddell"}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"sunday", true},
+		{" SUNDAY ", true},
+		{"liddell", true},
+		{"ostr", true},
+		{"#nostr", true},
+		{"#go", true},
+		{"#lang", false},
+		{"#sunday", false},
+		{"#", true},
+		{"bob", false},
+	}
+	for _, tt := range tests {
+		if got := listMatches(ev, nameMap, tt.query); got != tt.want {
+			t.Errorf("listMatches(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	if listMatches(ev, nil, "liddell") {
+		t.Errorf("matched a name not known")
+	}
+}
+
+func TestSetListFilter(t *testing.T) {
+	note := func(id, content string) nostr.Event {
+		return nostr.Event{ID: id, Content: content}
+	}
+	events := []nostr.Event{
+		note("1", "apple"),
+		note("2", "banana"),
+		note("3", "apple pie"),
+		note("4", "cherry"),
+		note("5", "apple tart"),
+	}
+	tests := []struct {
+		filter  string // before
+		cur     int
+		query   string
+		wantCur int
+		wantID  string
+	}{
+		{"", 2, "apple", 1, "3"},
+		{"", 4, "apple", 2, "5"},
+		{"", 3, "apple", 0, "1"},
+		{"apple", 1, "", 2, "3"},
+		{"apple", 2, "tart", 0, "5"},
+		{"", 0, "nothing", 0, ""},
+	}
+	for _, tt := range tests {
+		m := model{events: events, listFilter: tt.filter, listCur: tt.cur, listOffset: tt.cur}
+		m = setListFilter(m, tt.query)
+		visible := visibleEvents(m)
+		id := ""
+		if m.listCur < len(visible) {
+			id = visible[m.listCur].ID
+		}
+		if m.listFilter != tt.query || m.listCur != tt.wantCur || id != tt.wantID {
+			t.Errorf("%q -> %q from %d: filter %q, cursor %d on %q, want %d on %q",
+				tt.filter, tt.query, tt.cur, m.listFilter, m.listCur, id, tt.wantCur, tt.wantID)
+		}
+		if m.listOffset > m.listCur {
+			t.Errorf("%q -> %q: offset %d past cursor %d", tt.filter, tt.query, m.listOffset, m.listCur)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type peopleListsLoadedMsg struct {
+	from  string // whose lists; empty for ours
+	lists map[string]nip51List
+	err   error
+}
+
+func loadPeopleListsCmd(from string) tea.Cmd {
+	return func() tea.Msg {
+		if from == "" {
+			lists, err := syncPeopleLists()
+			return peopleListsLoadedMsg{lists: lists, err: err}
+		}
+		sets, err := fetchFollowSets(from)
+		lists := make(map[string]nip51List)
+		for name, ev := range sets {
+			ev := ev
+			if list, _ := followSet(name).parse(&ev); len(listMembers(list)) > 0 {
+				lists[name] = list
+			}
+		}
+		return peopleListsLoadedMsg{from: from, lists: lists, err: err}
+	}
+}
+
+func deletePeopleListCmd(name string) tea.Cmd {
+	return func() tea.Msg {
+		ev, statuses, err := deletePeopleList(name)
+		return listDoneMsg{label: "Lists", flash: "Deleted " + name, event: ev, statuses: statuses, err: err}
+	}
+}
+
+func importPeopleListCmd(from, name string) tea.Cmd {
+	return func() tea.Msg {
+		n, ev, statuses, err := importPeopleList(from, name, name)
+		flash := fmt.Sprintf("Imported %d into %s", n, name)
+		return listDoneMsg{label: "Lists", flash: flash, event: ev, statuses: statuses, err: err}
+	}
+}
+
+// setPersonListsCmd puts pubkey on the lists in add and takes it off those
+// in remove.
+func setPersonListsCmd(pubkey string, add, remove []string) tea.Cmd {
+	return func() tea.Msg {
+		msg := listDoneMsg{label: "Lists", flash: "Lists updated"}
+		item := []listItem{{Type: "p", Value: pubkey}}
+		for _, name := range add {
+			if msg.event, msg.statuses, msg.err = updatePeopleList(name, item, true); msg.err != nil {
+				return msg
+			}
+		}
+		for _, name := range remove {
+			if msg.event, msg.statuses, msg.err = updatePeopleList(name, item, false); msg.err != nil {
+				return msg
+			}
+		}
+		return msg
+	}
+}
+
+func openPeopleLists(m model) (model, tea.Cmd) {
+	if config.PrivateKey == "" {
+		m.flash = "Set key first (Optionen)"
+		return m, nil
+	}
+	m.screen = screenLists
+	m.listsFrom = ""
+	m.listsConfirm = ""
+	m.listsInputActive = false
+	m = setPeopleLists(m, loadPeopleLists())
+	m.listsLoading = true
+	m.err = ""
+	return m, loadPeopleListsCmd("")
+}
+
+func setPeopleLists(m model, lists map[string]nip51List) model {
+	m.peopleLists = lists
+	m.listNames = peopleListNames(lists)
+	if m.listsCur >= len(m.listNames) {
+		m.listsCur = len(m.listNames) - 1
+	}
+	if m.listsCur < 0 {
+		m.listsCur = 0
+	}
+	return m
+}
+
+// memberName is the petname or known name of pubkey.
+func memberName(m model, pubkey string) string {
+	if f, ok := config.Following[pubkey]; ok && f.Name != "" {
+		return f.Name
+	}
+	if name := m.nameMap[pubkey]; name != "" {
+		return name
+	}
+	return shorten(pubkey)
+}
+
+func updatePeopleLists(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case peopleListsLoadedMsg:
+		if msg.from != m.listsFrom {
+			return m, nil
+		}
+		m.listsLoading = false
+		m = setPeopleLists(m, msg.lists)
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.listsInputActive {
+			return updatePeopleListsInput(m, msg)
+		}
+		name := ""
+		if m.listsCur < len(m.listNames) {
+			name = m.listNames[m.listsCur]
+		}
+		if msg.String() != "x" {
+			m.listsConfirm = ""
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.listsCur > 0 {
+				m.listsCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.listsCur < len(m.listNames)-1 {
+				m.listsCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if name == "" {
+				return m, nil
+			}
+			if m.listsFrom != "" {
+				return m, importPeopleListCmd(m.listsFrom, name)
+			}
+			return openFeed(m, Feed{Name: "List " + name, Lists: []string{name}}, screenLists)
+		case "x":
+			if name == "" || m.listsFrom != "" {
+				return m, nil
+			}
+			if m.listsConfirm != name {
+				m.listsConfirm = name
+				m.flash = "Press x again to delete " + name
+				return m, nil
+			}
+			m.listsConfirm = ""
+			return m, deletePeopleListCmd(name)
+		case "i":
+			m.listsInput.Reset()
+			m.listsInputActive = true
+			return m, m.listsInput.Focus()
+		case "r":
+			m.listsLoading = true
+			return m, loadPeopleListsCmd(m.listsFrom)
+		case "u", "b", "esc":
+			if m.listsFrom != "" {
+				return openPeopleLists(m)
+			}
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updatePeopleListsInput reads whose lists to show for import.
+func updatePeopleListsInput(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.listsInputActive = false
+		m.listsInput.Blur()
+		return m, nil
+	case "enter":
+		arg := strings.TrimSpace(m.listsInput.Value())
+		m.listsInputActive = false
+		m.listsInput.Blur()
+		if arg == "" {
+			return m, nil
+		}
+		pubkey, err := pubkeyArg(arg)
+		if err != nil {
+			m.flash = arg + ": " + err.Error()
+			return m, nil
+		}
+		m.listsFrom = pubkey
+		m.listsCur = 0
+		m.listsLoading = true
+		m.err = ""
+		m = setPeopleLists(m, nil)
+		return m, loadPeopleListsCmd(pubkey)
+	}
+	var cmd tea.Cmd
+	m.listsInput, cmd = m.listsInput.Update(msg)
+	return m, cmd
+}
+
+func viewPeopleLists(m model) string {
+	width := m.width - 8
+	if width < 40 {
+		width = 40
+	}
+	title := "1  People lists"
+	if m.listsFrom != "" {
+		title = "1  Lists by " + memberName(m, m.listsFrom) + " (enter imports)"
+	}
+	s := tuiStyle.Base.Render(title) + "\n\n"
+	if m.err != "" {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
+	}
+	if m.listsLoading && len(m.listNames) == 0 {
+		s += tuiStyle.Base.Render("i  Loading lists...") + "\n"
+	} else if len(m.listNames) == 0 && m.listsFrom != "" {
+		s += tuiStyle.Base.Render("i  No people lists found.") + "\n"
+	} else if len(m.listNames) == 0 {
+		s += tuiStyle.Base.Render("i  No people lists. [l] on the Following screen puts someone on one.") + "\n"
+	}
+
+	avail := m.height - 10
+	if avail < 3 {
+		avail = 3
+	}
+	start := 0
+	if m.listsCur >= avail {
+		start = m.listsCur - avail + 1
+	}
+	for i := start; i < len(m.listNames) && i < start+avail; i++ {
+		name := m.listNames[i]
+		members := listMembers(m.peopleLists[name])
+		var names []string
+		for _, key := range members {
+			names = append(names, memberName(m, key))
+		}
+		line := fmt.Sprintf("1  %s (%d)  ", name, len(members))
+		line += draftPreview(strings.Join(names, ", "), width-len([]rune(line)))
+		if i == m.listsCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	if m.listsInputActive {
+		s += "\n" + tuiStyle.Base.Render("Import from: ") + m.listsInput.View() + "\n"
+		s += tuiStyle.Base.Render("i  [enter] show their lists  [esc] cancel") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.listsFrom != "" {
+		s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] import  [i] other pubkey  [r] refresh  [u] back to your lists") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] notes  [x] delete  [i] import from someone  [r] refresh  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// liveSub keeps a subscription open after a feed or thread has loaded, so
+// that what arrives later can be shown. The model asks for one event at a
+// time with next.
+type liveSub struct {
+	sub    *authorSub
+	accept func(nostr.Event) bool
+	seen   map[string]bool // only touched by the one pending next
+	seq    int             // feedSeq of the feed it follows
+	root   string          // or the note whose replies it follows
+}
+
+type liveStartedMsg struct{ live *liveSub }
+type liveEventMsg struct {
+	live  *liveSub
+	event nostr.Event
+}
+type liveNamesMsg struct{ nameMap map[string]string }
+
+// next waits for the next event not seen before; nil once l is closed.
+func (l *liveSub) next() tea.Cmd {
+	return func() tea.Msg {
+		for {
+			select {
+			case em := <-l.sub.Events:
+				if l.seen[em.Event.ID] || !l.accept(em.Event) {
+					continue
+				}
+				l.seen[em.Event.ID] = true
+				return liveEventMsg{live: l, event: em.Event}
+			case <-l.sub.done:
+				return nil
+			}
+		}
+	}
+}
+
+func (l *liveSub) Close() {
+	if l != nil {
+		l.sub.Close()
+	}
+}
+
+// liveFeedCmd subscribes to the events of f from since on.
+func liveFeedCmd(f Feed, seq int, since time.Time) tea.Cmd {
+	return func() tea.Msg {
+		filter, keys, err := f.filter(0)
+		if err != nil {
+			return nil
+		}
+		filter.Since = &since
+		authors := make(map[string]bool)
+		for _, key := range keys {
+			authors[key] = true
+		}
+		accept := func(ev nostr.Event) bool {
+			if currentMuteFilter().muted(ev) || !f.accepts(ev) {
+				return false
+			}
+			return len(keys) == 0 || authors[ev.PubKey] || f.interesting(ev)
+		}
+		sub := f.subscribe(filter, keys)
+		return liveStartedMsg{&liveSub{sub: sub, accept: accept, seen: make(map[string]bool), seq: seq}}
+	}
+}
+
+// liveRepliesCmd subscribes to the replies to root from since on.
+func liveRepliesCmd(root string, since time.Time) tea.Cmd {
+	return func() tea.Msg {
+		sub := subscribePool(nostr.Filters{{
+			Tags:  nostr.TagMap{"e": {root}},
+			Kinds: []int{nostr.KindTextNote},
+			Since: &since,
+		}})
+		accept := func(ev nostr.Event) bool {
+			return !currentMuteFilter().muted(ev)
+		}
+		return liveStartedMsg{&liveSub{sub: sub, accept: accept, seen: make(map[string]bool), root: root}}
+	}
+}
+
+// liveNamesCmd looks up the authors of events that arrived live and keeps
+// the notes for search.
+func liveNamesCmd(events []nostr.Event, nameMap map[string]string) tea.Cmd {
+	known := make(map[string]string, len(nameMap))
+	for k, v := range nameMap {
+		known[k] = v
+	}
+	return func() tea.Msg {
+		cacheEventsLater(events)
+		return liveNamesMsg{nameMap: fillNameMap(events, known)}
+	}
+}
+
+// updateLive handles live subscriptions whatever the screen: a feed keeps
+// collecting while one of its notes is open.
+func updateLive(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case liveStartedMsg:
+		l := msg.live
+		if l.root == "" && l.seq == m.feedSeq && m.feedLive == nil && !m.loading {
+			m.feedLive = l
+			return m, l.next()
+		}
+		if ev := detailCurrentEvent(m); l.root != "" && m.screen == screenDetail && ev != nil && ev.ID == l.root {
+			m.detailLive.Close()
+			m.detailLive = l
+			return m, l.next()
+		}
+		l.Close()
+	case liveEventMsg:
+		switch msg.live {
+		case m.feedLive:
+			for _, ev := range m.feedNew {
+				if ev.ID == msg.event.ID {
+					return m, msg.live.next()
+				}
+			}
+			for _, ev := range m.events {
+				if ev.ID == msg.event.ID {
+					return m, msg.live.next()
+				}
+			}
+			m.feedNew = append(m.feedNew, msg.event)
+			return m, msg.live.next()
+		case m.detailLive:
+			ev := detailCurrentEvent(m)
+			if m.screen != screenDetail || ev == nil || ev.ID != msg.live.root {
+				m.detailLive.Close()
+				m.detailLive = nil
+				return m, nil
+			}
+			for _, reply := range m.detailReplies {
+				if reply.ID == msg.event.ID {
+					return m, msg.live.next()
+				}
+			}
+			// replies are oldest first, so new ones go below
+			m.detailReplies = append(m.detailReplies, msg.event)
+			if _, ok := m.nameMap[msg.event.PubKey]; !ok {
+				return m, tea.Batch(msg.live.next(), liveNamesCmd([]nostr.Event{msg.event}, m.nameMap))
+			}
+			return m, msg.live.next()
+		}
+		msg.live.Close()
+	case liveNamesMsg:
+		for k, v := range msg.nameMap {
+			if v != "" {
+				m.nameMap[k] = v
+			}
+		}
+	}
+	return m, nil
+}
+
+// stopLiveFeed closes the subscription of the list and drops the notes not
+// shown yet.
+func stopLiveFeed(m model) model {
+	m.feedLive.Close()
+	m.feedLive = nil
+	m.feedNew = nil
+	return m
+}
+
+// showNewNotes merges the notes that arrived live into the list, keeping
+// the cursor on the note it was on.
+func showNewNotes(m model) (model, tea.Cmd) {
+	if len(m.feedNew) == 0 {
+		return m, nil
+	}
+	current := ""
+	if events := visibleEvents(m); m.listCur >= 0 && m.listCur < len(events) {
+		current = events[m.listCur].ID
+	}
+	fresh := m.feedNew
+	sort.Slice(fresh, func(i, j int) bool {
+		return fresh[i].CreatedAt.After(fresh[j].CreatedAt)
+	})
+	m.events = append(fresh, m.events...)
+	m.feedNew = nil
+	m.err = ""
+	for i, ev := range visibleEvents(m) {
+		if ev.ID == current {
+			m.listCur = i
+			break
+		}
+	}
+	m.listOffset = clampListOffset(m)
+	return m, liveNamesCmd(fresh, m.nameMap)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -8,40 +9,60 @@ import (
 )
 
 const (
-	menuItemHome = iota
-	menuItemHomeComments
-	menuItemAether
+	menuItemFeed = iota
 	menuItemComposeNote
-	menuItemInbox
+	menuItemDrafts
+	menuItemBookmarks
+	menuItemLists
+	menuItemHashtags
+	menuItemSearch
 	menuItemFollowing
 	menuItemFollow
 	menuItemOptions
 	menuItemQuit
-	menuItemCount
 )
 
 const (
 	optItemRelays = iota
 	optItemSetKey
 	optItemAllowImageASCII
+	optItemPublishLog
+	optItemEditProfile
 	optItemCount
 )
 
-var menuItems = []struct {
+type menuEntry struct {
 	prefix string
 	label  string
-}{
-	{"1", " Home"},
-	{"2", " Notes + Comments"},
-	{"3", " Aether"},
-	{"4", " Publish note"},
-	{"5", " Inbox"},
-	{"6", " Following"},
-	{"7", " Follow"},
-	{"8", " Optionen"},
+	action int
+	feed   int // index into config.Feeds for menuItemFeed
 }
 
-var menuItemQuitLabel = "9  Quit"
+// menuActions follow the configured feeds, which are numbered 1-9.
+var menuActions = []menuEntry{
+	{"p", " Publish note", menuItemComposeNote, 0},
+	{"d", " Drafts", menuItemDrafts, 0},
+	{"b", " Bookmarks", menuItemBookmarks, 0},
+	{"l", " Lists", menuItemLists, 0},
+	{"#", " Hashtags", menuItemHashtags, 0},
+	{"/", " Search", menuItemSearch, 0},
+	{"f", " Following", menuItemFollowing, 0},
+	{"+", " Follow", menuItemFollow, 0},
+	{"o", " Optionen", menuItemOptions, 0},
+	{"0", " Quit", menuItemQuit, 0},
+}
+
+func menuEntries() []menuEntry {
+	var entries []menuEntry
+	for i, f := range config.Feeds {
+		prefix := " "
+		if i < 9 {
+			prefix = strconv.Itoa(i + 1)
+		}
+		entries = append(entries, menuEntry{prefix, " " + f.Name, menuItemFeed, i})
+	}
+	return append(entries, menuActions...)
+}
 
 var optItems = []struct {
 	prefix string
@@ -50,34 +71,39 @@ var optItems = []struct {
 	{"1", " Relays"},
 	{"2", " Set key (nsec)"},
 	{"3", " Allow image-to-ASCII"},
+	{"4", " Publish log"},
+	{"5", " Edit profile"},
 }
 
 func updateMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		key := msg.String()
-		if key >= "1" && key <= "9" {
-			idx := int(key[0] - '1')
-			if idx < menuItemCount {
+		entries := menuEntries()
+		for idx, entry := range entries {
+			if key == entry.prefix {
 				m.menuCur = idx
-				return runMenuAction(m, idx)
+				return runMenuAction(m, entry)
 			}
 		}
 		switch key {
 		case "up", "k":
 			m.menuCur--
 			if m.menuCur < 0 {
-				m.menuCur = menuItemCount - 1
+				m.menuCur = len(entries) - 1
 			}
 			return m, nil
 		case "down", "j":
 			m.menuCur++
-			if m.menuCur >= menuItemCount {
+			if m.menuCur >= len(entries) {
 				m.menuCur = 0
 			}
 			return m, nil
 		case "enter", " ":
-			return runMenuAction(m, m.menuCur)
+			if m.menuCur >= len(entries) {
+				m.menuCur = 0
+			}
+			return runMenuAction(m, entries[m.menuCur])
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		}
@@ -85,48 +111,34 @@ func updateMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func runMenuAction(m model, idx int) (tea.Model, tea.Cmd) {
-	switch idx {
-	case menuItemHome:
-		m.screen = screenList
-		m.loading = true
-		m.inbox = false
-		m.notesOnly = true
-		m.aether = false
-		m.events = nil
-		return m, loadFeedCmd(false, true, false)
-	case menuItemHomeComments:
-		m.screen = screenList
-		m.loading = true
-		m.inbox = false
-		m.notesOnly = false
-		m.aether = false
-		m.events = nil
-		return m, loadFeedCmd(false, false, false)
-	case menuItemAether:
-		m.screen = screenList
-		m.loading = true
-		m.inbox = false
-		m.notesOnly = false
-		m.aether = true
-		m.events = nil
-		return m, loadFeedCmd(false, false, true)
+func runMenuAction(m model, entry menuEntry) (tea.Model, tea.Cmd) {
+	switch entry.action {
+	case menuItemFeed:
+		if entry.feed >= len(config.Feeds) {
+			return m, nil
+		}
+		return openFeed(m, config.Feeds[entry.feed], screenMenu)
 	case menuItemComposeNote:
 		m.screen = screenComposeNote
-		m.composeInput.Reset()
-		m.composeInput.Focus()
+		m = resetComposeNote(m)
+		m.composeReturn = screenMenu
+		m.composeArea.Placeholder = "Your note..."
 		m.err = ""
-		return m, textinput.Blink
-	case menuItemInbox:
-		m.screen = screenList
-		m.loading = true
-		m.inbox = true
-		m.aether = false
-		m.events = nil
-		return m, loadFeedCmd(true, false, false)
+		return m, m.composeArea.Focus()
+	case menuItemDrafts:
+		m = openDrafts(m)
+		return m, nil
+	case menuItemBookmarks:
+		return openBookmarks(m)
+	case menuItemLists:
+		return openPeopleLists(m)
+	case menuItemHashtags:
+		return openHashtags(m)
+	case menuItemSearch:
+		return openSearch(m)
 	case menuItemFollowing:
 		m.screen = screenFollowing
-		m.followLines = buildFollowLines()
+		m = refreshFollowing(m)
 		return m, nil
 	case menuItemFollow:
 		m.screen = screenFollow
@@ -148,7 +160,7 @@ func updateOptions(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		key := msg.String()
-		if key >= "1" && key <= "3" {
+		if len(key) == 1 && key[0] >= '1' && key[0] < '1'+optItemCount {
 			idx := int(key[0] - '1')
 			if idx < optItemCount {
 				m.menuCur = idx
@@ -196,6 +208,10 @@ func runOptAction(m model, idx int) (tea.Model, tea.Cmd) {
 		config.AllowImageASCII = !config.AllowImageASCII
 		saveConfig(tuiConfigPath)
 		return m, nil
+	case optItemPublishLog:
+		return openPublishLog(m), nil
+	case optItemEditProfile:
+		return openEditProfile(m)
 	}
 	return m, nil
 }
@@ -205,19 +221,21 @@ func viewMenu(m model) string {
 	banner := strings.Split(gostrTitle, "\n")
 	lines = append(lines, banner...)
 	lines = append(lines, "")
-	menuStart := len(lines)
-	for i, item := range menuItems {
-		lines = append(lines, "  "+item.prefix+item.label)
-		if i == 2 {
+	// entryOf maps a line to its menu entry; -1 for the others
+	entryOf := make([]int, len(lines))
+	for i := range entryOf {
+		entryOf[i] = -1
+	}
+	for i, entry := range menuEntries() {
+		if i > 0 && (i == len(config.Feeds) || entry.action == menuItemQuit) {
 			lines = append(lines, "")
+			entryOf = append(entryOf, -1)
 		}
+		lines = append(lines, "  "+entry.prefix+entry.label)
+		entryOf = append(entryOf, i)
 	}
 	lines = append(lines, "")
-	lines = append(lines, "  "+menuItemQuitLabel)
-	quitIndex := len(lines) - 1
-	lines = append(lines, "")
-	footerIndex := len(lines)
-	lines = append(lines, "i  [1-9] select  [j/k] move  [q] quit")
+	lines = append(lines, "i  [1-9/letters] select  [j/k] move  [q] quit")
 
 	// vertical centering
 	h := m.height
@@ -253,27 +271,9 @@ func viewMenu(m model) string {
 		}
 		padded := strings.Repeat(" ", pad) + line
 
-		// decide style: menu lines get cursor highlighting (blank at menuStart+3 is not a menu item)
-		menuIdx := -1
-		if idx >= menuStart && idx < menuStart+len(menuItems)+1 {
-			if idx < menuStart+3 {
-				menuIdx = idx - menuStart
-			} else if idx > menuStart+3 {
-				menuIdx = idx - menuStart - 1
-			}
-		}
-		if menuIdx >= 0 && menuIdx < len(menuItems) {
-			if menuIdx == m.menuCur {
-				b.WriteString(tuiStyle.Cursor.Render(padded))
-			} else {
-				b.WriteString(tuiStyle.Base.Render(padded))
-			}
-		} else if idx == quitIndex && m.menuCur == menuItemQuit {
+		// menu lines get cursor highlighting
+		if idx < len(entryOf) && entryOf[idx] >= 0 && entryOf[idx] == m.menuCur {
 			b.WriteString(tuiStyle.Cursor.Render(padded))
-		} else if idx == quitIndex {
-			b.WriteString(tuiStyle.Base.Render(padded))
-		} else if idx == footerIndex {
-			b.WriteString(tuiStyle.Base.Render(padded))
 		} else {
 			b.WriteString(tuiStyle.Base.Render(padded))
 		}
@@ -301,7 +301,7 @@ func viewOptions(m model) string {
 	}
 	lines = append(lines, "")
 	footerIndex := len(lines)
-	lines = append(lines, "i  [1-3] select  [j/k] move  [u] back  [q] quit")
+	lines = append(lines, "i  [1-5] select  [j/k] move  [u] back  [q] quit")
 
 	h := m.height
 	if h <= 0 {
@@ -363,23 +363,6 @@ func buildRelayLinesAndURLs() (lines []string, urls []string) {
 	return lines, urls
 }
 
-func buildFollowLines() []string {
-	if len(config.Following) == 0 {
-		return []string{"i  Not following anyone."}
-	}
-	lines := make([]string, 0, len(config.Following))
-	for _, f := range config.Following {
-		name := f.Key
-		if f.Name != "" {
-			name = f.Name + " (" + shorten(f.Key) + ")"
-		} else {
-			name = shorten(f.Key)
-		}
-		lines = append(lines, "1  "+name)
-	}
-	return lines
-}
-
 func updateRelays(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -436,26 +419,3 @@ func viewRelays(m model) string {
 	s += "\n" + tuiStyle.Base.Render("i  [a] add  [r] remove  [u] back") + "\n"
 	return tuiStyle.Screen.Render(s)
 }
-
-func updateFollowing(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "u", "b", "esc":
-			m.screen = screenMenu
-			return m, nil
-		case "q":
-			return m, tea.Quit
-		}
-	}
-	return m, nil
-}
-
-func viewFollowing(m model) string {
-	s := tuiStyle.Base.Render("i  Following") + "\n\n"
-	for _, line := range m.followLines {
-		s += tuiStyle.Base.Render(line) + "\n"
-	}
-	s += "\n" + tuiStyle.Base.Render("i  [u] back") + "\n"
-	return tuiStyle.Screen.Render(s)
-}
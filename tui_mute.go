@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type muteDoneMsg struct {
+	items    []listItem
+	mute     bool
+	event    *nostr.Event
+	statuses chan deliveryStatus
+	err      error
+}
+
+func muteCmd(items []listItem, mute bool) tea.Cmd {
+	return func() tea.Msg {
+		ev, statuses, err := updateMutes(items, mute)
+		return muteDoneMsg{items: items, mute: mute, event: ev, statuses: statuses, err: err}
+	}
+}
+
+// updateMuteDone hides what was just muted from the loaded feed and thread.
+func updateMuteDone(m model, msg muteDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.flash = "Mute list: " + msg.err.Error()
+		return m, nil
+	}
+	m.events = filterMuted(m.events)
+	if n := len(visibleEvents(m)); m.listCur >= n {
+		m.listCur = n - 1
+	}
+	if m.listCur < 0 {
+		m.listCur = 0
+	}
+	m.listOffset = clampListOffset(m)
+	m.detailReplies = filterMuted(m.detailReplies)
+	if m.detailReplyCur >= len(m.detailReplies) {
+		m.detailReplyCur = 0
+	}
+	m.profile.notes = filterMuted(m.profile.notes)
+	if m.profileCur >= len(m.profile.notes) {
+		m.profileCur = 0
+	}
+
+	label := muteLabel(msg.items[0])
+	if len(msg.items) > 1 {
+		label = fmt.Sprintf("%d items", len(msg.items))
+	}
+	if msg.mute {
+		m.flash = "Muted " + label
+	} else {
+		m.flash = "Unmuted " + label
+	}
+	return watchPublish(m, "Mute list", msg.event, msg.statuses)
+}
+
+// detailMuteItems returns what a key of the detail view's mute prompt mutes;
+// upper case keeps the items private.
+func detailMuteItems(m model, key string) []listItem {
+	ev := detailCurrentEvent(m)
+	if ev == nil {
+		return nil
+	}
+	private := key == "A" || key == "T" || key == "H"
+	switch key {
+	case "a", "A":
+		return []listItem{{Type: "p", Value: ev.PubKey, Private: private}}
+	case "t", "T":
+		return []listItem{{Type: "e", Value: m.detailStack[0].ID, Private: private}}
+	case "h", "H":
+		var items []listItem
+		for _, tag := range ev.Tags.GetAll([]string{"t", ""}) {
+			items = append(items, listItem{Type: "t", Value: tag.Value(), Private: private})
+		}
+		return items
+	}
+	return nil
+}
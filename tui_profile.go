@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+)
+
+const (
+	profilePictureWidth = 30
+	maxFollowersCounted = 500
+)
+
+// profileData is what the Profile screen shows about someone.
+type profileData struct {
+	meta      Metadata
+	hasMeta   bool
+	nip05OK   bool          // meta.NIP05 resolves to this pubkey
+	following int           // p-tags in their contact list, -1 if none found
+	followers int           // distinct contact lists seen that contain them
+	notes     []nostr.Event // pinned notes first
+	pinned    map[string]bool
+	nameMap   map[string]string
+	likedMap  map[string]string
+	boosted   map[string]string
+}
+
+type profileLoadedMsg struct {
+	pubkey string
+	data   profileData
+}
+
+type profilePictureMsg struct {
+	pubkey  string
+	content string
+}
+
+// loadProfile fetches metadata, contact list, followers, pinned and recent
+// notes of pubkey in parallel.
+func loadProfile(pubkey string) tea.Msg {
+	data := profileData{following: -1}
+	var wg sync.WaitGroup
+	var pinned []nostr.Event
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		sub := subscribeAuthors(nostr.Filter{Kinds: []int{nostr.KindSetMetadata, nostr.KindContactList}}, []string{pubkey})
+		defer sub.Close()
+		var meta, contacts *nostr.Event
+		for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+			ev := ev
+			if ev.PubKey != pubkey {
+				continue
+			}
+			switch {
+			case ev.Kind == nostr.KindSetMetadata && (meta == nil || ev.CreatedAt.After(meta.CreatedAt)):
+				meta = &ev
+			case ev.Kind == nostr.KindContactList && (contacts == nil || ev.CreatedAt.After(contacts.CreatedAt)):
+				contacts = &ev
+			}
+		}
+		if meta != nil && json.Unmarshal([]byte(meta.Content), &data.meta) == nil {
+			data.hasMeta = true
+		}
+		if contacts != nil {
+			data.following = len(parseContactList(contacts))
+		}
+		if data.meta.NIP05 != "" {
+			data.nip05OK = checkNIP05(data.meta.NIP05, pubkey)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		seen := make(map[string]bool)
+		sub := subscribePool(nostr.Filters{{
+			Kinds: []int{nostr.KindContactList},
+			Tags:  nostr.TagMap{"p": {pubkey}},
+			Limit: maxFollowersCounted,
+		}})
+		defer sub.Close()
+		for ev := range iterEventsWithTimeout(nostr.Unique(sub.Events), 3*time.Second) {
+			if ev.Kind == nostr.KindContactList {
+				seen[ev.PubKey] = true
+			}
+		}
+		data.followers = len(seen)
+	}()
+	go func() {
+		defer wg.Done()
+		feed := loadFeed(Feed{Authors: []string{pubkey}}, time.Time{})
+		data.notes = feed.events
+		data.nameMap = feed.nameMap
+		data.likedMap = feed.likedMap
+		data.boosted = feed.boostedMap
+	}()
+	go func() {
+		defer wg.Done()
+		pinned = filterMuted(fetchPins(pubkey))
+	}()
+	wg.Wait()
+
+	data.pinned = make(map[string]bool)
+	notes := pinned
+	for _, ev := range pinned {
+		data.pinned[ev.ID] = true
+	}
+	for _, ev := range data.notes {
+		if !data.pinned[ev.ID] {
+			notes = append(notes, ev)
+		}
+	}
+	data.notes = notes
+	return profileLoadedMsg{pubkey: pubkey, data: data}
+}
+
+func loadProfilePictureCmd(pubkey, url string) tea.Cmd {
+	return func() tea.Msg {
+		content, err := fetchAndConvertToASCII(url, profilePictureWidth)
+		if err != nil {
+			content = ""
+		}
+		return profilePictureMsg{pubkey: pubkey, content: content}
+	}
+}
+
+// openProfile shows the profile of pubkey; back leads to the current screen.
+func openProfile(m model, pubkey string) (model, tea.Cmd) {
+	m.profileReturn = m.screen
+	m.profileDetailStack = nil
+	if m.screen == screenDetail {
+		m.profileDetailStack = m.detailStack
+	}
+	m.screen = screenProfile
+	m.profilePubkey = pubkey
+	m.profile = profileData{following: -1}
+	m.profileLoading = true
+	m.profilePicture = ""
+	m.profileCur = 0
+	m.err = ""
+	return m, func() tea.Msg { return loadProfile(pubkey) }
+}
+
+func profileName(m model) string {
+	if m.profile.meta.Name != "" {
+		return m.profile.meta.Name
+	}
+	if f, ok := config.Following[m.profilePubkey]; ok && f.Name != "" {
+		return f.Name
+	}
+	return shorten(m.profilePubkey)
+}
+
+func updateProfile(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case profileLoadedMsg:
+		if msg.pubkey != m.profilePubkey {
+			return m, nil
+		}
+		m.profile = msg.data
+		m.profileLoading = false
+		for k, v := range msg.data.nameMap {
+			if v != "" {
+				m.nameMap[k] = v
+			}
+		}
+		if config.AllowImageASCII && m.profile.meta.Picture != "" {
+			return m, loadProfilePictureCmd(msg.pubkey, m.profile.meta.Picture)
+		}
+		return m, nil
+	case profilePictureMsg:
+		if msg.pubkey == m.profilePubkey {
+			m.profilePicture = msg.content
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.profileCur > 0 {
+				m.profileCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.profileCur < len(m.profile.notes)-1 {
+				m.profileCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if m.profileCur >= len(m.profile.notes) {
+				return m, nil
+			}
+			ev := m.profile.notes[m.profileCur]
+			for k, v := range m.profile.likedMap {
+				m.likedMap[k] = v
+			}
+			for k, v := range m.profile.boosted {
+				m.boostedMap[k] = v
+			}
+			m.screen = screenDetail
+			m.detailReturn = screenProfile
+			m.detailStack = []nostr.Event{ev}
+			m.detailReplies = nil
+			m.detailReplyCur = 0
+			m.detailRepliesLoading = true
+			m.detailStatus = ""
+			return m, loadRepliesCmd(ev.ID)
+		case "f":
+			if _, ok := config.Following[m.profilePubkey]; ok {
+				delete(config.Following, m.profilePubkey)
+				m.flash = "Unfollowed " + profileName(m)
+			} else {
+				config.Following[m.profilePubkey] = Follow{Key: m.profilePubkey, Name: m.profile.meta.Name}
+				m.flash = "Following " + profileName(m)
+			}
+			saveConfig(tuiConfigPath)
+			return m, nil
+		case "m":
+			m.screen = screenComposeMessage
+			m.composeReturn = screenProfile
+			m.composeFollowKeys = nil
+			m.composeRecipientCur = 0
+			m.composeRecipientSelected = m.profilePubkey
+			m.composeToInput.Reset()
+			m.composeInput.Reset()
+			m.composeToInput.Blur()
+			m.composeInput.Placeholder = "Message to " + profileName(m) + "..."
+			m.err = ""
+			return m, m.composeInput.Focus()
+		case "M":
+			if config.PrivateKey == "" {
+				m.flash = "Set key first"
+				return m, nil
+			}
+			muted := currentMuteFilter().pubkeys[m.profilePubkey]
+			return m, muteCmd([]listItem{{Type: "p", Value: m.profilePubkey}}, !muted)
+		case "c":
+			if npub, err := nip19.EncodePublicKey(m.profilePubkey, ""); err == nil {
+				_ = clipboard.WriteAll(npub)
+				m.flash = "npub copied"
+			}
+			return m, nil
+		case "r":
+			back, stack := m.profileReturn, m.profileDetailStack
+			m.screen = back
+			m, cmd := openProfile(m, m.profilePubkey)
+			m.profileReturn, m.profileDetailStack = back, stack
+			return m, cmd
+		case "u", "b", "esc":
+			m.screen = m.profileReturn
+			switch m.screen {
+			case screenFollowing:
+				m = refreshFollowing(m)
+			case screenDetail:
+				if len(m.profileDetailStack) > 0 {
+					m.detailStack = m.profileDetailStack
+					m.detailReplies = nil
+					m.detailReplyCur = 0
+					m.detailRepliesLoading = true
+					return m, loadRepliesCmd(m.detailStack[len(m.detailStack)-1].ID)
+				}
+			}
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func viewProfile(m model) string {
+	width := m.width - 8
+	if width < 40 {
+		width = 40
+	}
+	s := tuiStyle.Base.Render("1  "+profileName(m)) + "\n"
+	npub, _ := nip19.EncodePublicKey(m.profilePubkey, "")
+	s += tuiStyle.Base.Render("i  "+npub) + "\n\n"
+	if m.profileLoading {
+		s += tuiStyle.Base.Render("i  Loading profile...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+
+	if m.profilePicture != "" {
+		for _, line := range strings.Split(strings.TrimRight(m.profilePicture, "\n"), "\n") {
+			s += tuiStyle.Base.Render("  "+line) + "\n"
+		}
+		s += "\n"
+	}
+	meta := m.profile.meta
+	if !m.profile.hasMeta {
+		s += tuiStyle.Base.Render("i  No profile metadata found.") + "\n"
+	}
+	if about := strings.TrimSpace(meta.About); about != "" {
+		for _, line := range strings.Split(wrap(about, width), "\n") {
+			s += tuiStyle.Base.Render("i  "+line) + "\n"
+		}
+	}
+	if meta.NIP05 != "" {
+		mark := "✗ not verified"
+		if m.profile.nip05OK {
+			mark = "✓"
+		}
+		s += tuiStyle.Base.Render("i  nip05: "+meta.NIP05+" "+mark) + "\n"
+	}
+	if meta.LUD16 != "" {
+		s += tuiStyle.Base.Render("i  lightning: "+meta.LUD16) + "\n"
+	}
+	if meta.Website != "" {
+		s += tuiStyle.Base.Render("i  web: "+meta.Website) + "\n"
+	}
+	following := "?"
+	if m.profile.following >= 0 {
+		following = fmt.Sprint(m.profile.following)
+	}
+	followers := fmt.Sprint(m.profile.followers)
+	if m.profile.followers >= maxFollowersCounted {
+		followers += "+"
+	}
+	counts := "i  following " + following + "  followers " + followers
+	if _, ok := config.Following[m.profilePubkey]; ok {
+		counts += "  (you follow them)"
+	}
+	if currentMuteFilter().pubkeys[m.profilePubkey] {
+		counts += "  (muted)"
+	}
+	s += tuiStyle.Base.Render(counts) + "\n\n"
+
+	s += tuiStyle.Base.Render("1  Recent notes") + "\n"
+	if len(m.profile.notes) == 0 {
+		s += tuiStyle.Base.Render("i  No notes found.") + "\n"
+	}
+	used := strings.Count(s, "\n")
+	avail := m.height - used - 5
+	if avail < 3 {
+		avail = 3
+	}
+	start := 0
+	if m.profileCur >= avail {
+		start = m.profileCur - avail + 1
+	}
+	for i := start; i < len(m.profile.notes) && i < start+avail; i++ {
+		ev := m.profile.notes[i]
+		line := "0  " + draftPreview(ev.Content, width-16) + "  " + shortTime(ev.CreatedAt)
+		if m.profile.pinned[ev.ID] {
+			line = "0  [pinned] " + draftPreview(ev.Content, width-25) + "  " + shortTime(ev.CreatedAt)
+		}
+		if i == m.profileCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	followKey := "[f] follow"
+	if _, ok := config.Following[m.profilePubkey]; ok {
+		followKey = "[f] unfollow"
+	}
+	muteKey := "[M] mute"
+	if currentMuteFilter().pubkeys[m.profilePubkey] {
+		muteKey = "[M] unmute"
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] notes  [enter] open  "+followKey+"  "+muteKey+"  [m] message  [c] copy npub  [r] refresh  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
+
+// shortTime formats t compactly for one-line lists.
+func shortTime(t time.Time) string {
+	if time.Since(t) < 24*time.Hour {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// publishPanelTimeout is how long the publish panel stays up after the last
+// relay answered.
+const publishPanelTimeout = 6 * time.Second
+
+var publishActionLabels = map[string]string{
+	"like":    "Like",
+	"unlike":  "Unlike",
+	"boost":   "Boost",
+	"unboost": "Unboost",
+}
+
+// publishRow is one relay's state in the publish panel.
+type publishRow struct {
+	relay   string
+	state   string // "sending" "sent" "ok" "rejected" "retry"
+	message string
+}
+
+// publishPanel shows the per-relay outcome of the last write action.
+type publishPanel struct {
+	id    string
+	label string
+	rows  []publishRow
+	done  bool
+}
+
+type publishStatusMsg struct {
+	id       string
+	status   deliveryStatus
+	statuses chan deliveryStatus
+}
+type publishDoneMsg struct{ id string }
+type publishExpireMsg struct{ id string }
+type publishRetriedMsg struct{ delivered, failed int }
+
+// watchPublish opens the publish panel for ev and starts following statuses.
+func watchPublish(m model, label string, ev *nostr.Event, statuses chan deliveryStatus) (model, tea.Cmd) {
+	if ev == nil || statuses == nil {
+		return m, nil
+	}
+	panel := publishPanel{id: ev.ID, label: label}
+	for _, relay := range writeRelays() {
+		panel.rows = append(panel.rows, publishRow{relay: relay, state: "sending"})
+	}
+	m.publish = panel
+	return m, waitDeliveryCmd(ev.ID, statuses)
+}
+
+// waitDeliveryCmd reports the next status from statuses, or publishDoneMsg
+// once the channel is closed.
+func waitDeliveryCmd(id string, statuses chan deliveryStatus) tea.Cmd {
+	return func() tea.Msg {
+		status, ok := <-statuses
+		if !ok {
+			return publishDoneMsg{id: id}
+		}
+		return publishStatusMsg{id: id, status: status, statuses: statuses}
+	}
+}
+
+// updatePublish handles the publish panel messages, whatever screen is active.
+func updatePublish(m model, msg tea.Msg) (model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case publishStatusMsg:
+		if msg.id == m.publish.id {
+			m.publish = m.publish.apply(msg.status)
+		}
+		return m, waitDeliveryCmd(msg.id, msg.statuses)
+	case publishDoneMsg:
+		m.outboxPending, m.outboxFailed = outboxCounts()
+		if m.screen == screenPublishLog {
+			m.publishLog = readOutbox()
+		}
+		if msg.id != m.publish.id {
+			return m, nil
+		}
+		m.publish.done = true
+		return m, tea.Tick(publishPanelTimeout, func(time.Time) tea.Msg {
+			return publishExpireMsg{id: msg.id}
+		})
+	case publishExpireMsg:
+		if msg.id == m.publish.id {
+			m.publish = publishPanel{}
+		}
+	}
+	return m, nil
+}
+
+// apply returns a copy of p with status recorded on its relay's row.
+func (p publishPanel) apply(status deliveryStatus) publishPanel {
+	row := publishRow{relay: status.Relay, message: status.Message}
+	switch {
+	case status.Status == nostr.PublishStatusSent:
+		row.state = "sent"
+	case status.Status == nostr.PublishStatusSucceeded:
+		row.state = "ok"
+	case status.Retry:
+		row.state = "retry"
+	default:
+		row.state = "rejected"
+	}
+	rows := make([]publishRow, 0, len(p.rows)+1)
+	found := false
+	for _, r := range p.rows {
+		if r.relay == row.relay {
+			// a late "sent" must not overwrite the final answer
+			if row.state == "sent" && r.state != "sending" {
+				row = r
+			}
+			r = row
+			found = true
+		}
+		rows = append(rows, r)
+	}
+	if !found {
+		rows = append(rows, row)
+	}
+	p.rows = rows
+	return p
+}
+
+var publishStateMarks = map[string]string{
+	"sending":  "..",
+	"sent":     "->",
+	"ok":       "✓ ",
+	"rejected": "✗ ",
+	"retry":    "↻ ",
+}
+
+// viewPublishPanel renders the panel below the current screen.
+func viewPublishPanel(m model) string {
+	if m.publish.id == "" {
+		return ""
+	}
+	ok := 0
+	for _, r := range m.publish.rows {
+		if r.state == "ok" {
+			ok++
+		}
+	}
+	header := fmt.Sprintf("i  %s %s: %d/%d relays accepted", m.publish.label, shorten(m.publish.id), ok, len(m.publish.rows))
+	if !m.publish.done {
+		header += " (publishing...)"
+	}
+	s := tuiStyle.Base.Render(header) + "\n"
+	for _, r := range m.publish.rows {
+		line := "i    " + publishStateMarks[r.state] + " " + r.relay
+		switch r.state {
+		case "sent":
+			line += "  waiting for OK"
+		case "retry":
+			line += "  will retry"
+		}
+		if r.message != "" {
+			line += "  " + r.message
+		}
+		s += tuiStyle.Base.Render(line) + "\n"
+	}
+	return s
+}
+
+func openPublishLog(m model) model {
+	m.screen = screenPublishLog
+	m.publishLog = readOutbox()
+	if m.publishLogCur >= len(m.publishLog) {
+		m.publishLogCur = 0
+	}
+	return m
+}
+
+func retryOutboxCmd() tea.Msg {
+	delivered, failed := flushOutbox(true)
+	return publishRetriedMsg{delivered: delivered, failed: failed}
+}
+
+func updatePublishLog(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case publishRetriedMsg:
+		m = openPublishLog(m)
+		m.outboxPending, m.outboxFailed = outboxCounts()
+		m.flash = fmt.Sprintf("Retried: %d delivered, %d failed", msg.delivered, msg.failed)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.publishLogCur > 0 {
+				m.publishLogCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.publishLogCur < len(m.publishLog)-1 {
+				m.publishLogCur++
+			}
+			return m, nil
+		case "r":
+			return openPublishLog(m), nil
+		case "R":
+			m.flash = "Retrying pending and failed deliveries..."
+			return m, retryOutboxCmd
+		case "u", "b", "esc":
+			m.screen = screenOptions
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// publishLogEntryLines renders one outbox entry with a line per relay.
+func publishLogEntryLines(e outboxEntry, width int) []string {
+	kind, ok := kindNames[e.Event.Kind]
+	if !ok {
+		kind = fmt.Sprintf("Kind %d", e.Event.Kind)
+	}
+	header := fmt.Sprintf("0  %s [%s] %s", kind, shorten(e.Event.ID), humanize.Time(e.Created))
+	if e.Event.Kind == nostr.KindTextNote {
+		header += "  " + draftPreview(e.Event.Content, width-len(header))
+	}
+	lines := []string{header}
+	relays := make([]string, 0, len(e.Relays))
+	for relay := range e.Relays {
+		relays = append(relays, relay)
+	}
+	sort.Strings(relays)
+	for _, relay := range relays {
+		d := e.Relays[relay]
+		line := fmt.Sprintf("i    %-8s %s", d.Status, relay)
+		if d.Status == deliveryPending && d.Attempts > 0 {
+			line += fmt.Sprintf(" (attempt %d, next %s)", d.Attempts, humanize.Time(d.NextTry))
+		}
+		if d.Message != "" {
+			line += ": " + d.Message
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func viewPublishLog(m model) string {
+	width := m.width - 8
+	if width < 30 {
+		width = 30
+	}
+	s := tuiStyle.Base.Render("1  Publish log") + "\n\n"
+	if len(m.publishLog) == 0 {
+		s += tuiStyle.Base.Render("i  Nothing published recently.") + "\n"
+	}
+	// scroll so that the selected entry is the last one that fits
+	avail := m.height - 10
+	if avail < 5 {
+		avail = 5
+	}
+	start, used := m.publishLogCur, 0
+	for start >= 0 && start < len(m.publishLog) {
+		used += 1 + len(m.publishLog[start].Relays)
+		if used > avail || start == 0 {
+			break
+		}
+		start--
+	}
+	if used > avail && start < m.publishLogCur {
+		start++
+	}
+	used = 0
+	for i := start; i < len(m.publishLog); i++ {
+		lines := publishLogEntryLines(m.publishLog[i], width)
+		if used+len(lines) > avail && i > start {
+			break
+		}
+		used += len(lines)
+		for j, line := range lines {
+			if i == m.publishLogCur && j == 0 {
+				s += tuiStyle.Cursor.Render(line) + "\n"
+			} else {
+				s += tuiStyle.Base.Render(line) + "\n"
+			}
+		}
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] nav  [r] refresh  [R] retry pending/failed  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
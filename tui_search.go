@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type searchDoneMsg struct {
+	seq     int
+	results []searchResult
+	relays  []string
+	nameMap map[string]string
+}
+
+func searchCmd(text string, seq int) tea.Cmd {
+	return func() tea.Msg {
+		results, relays := runSearch(searchQuery{Text: text})
+		events := make([]nostr.Event, len(results))
+		for i, r := range results {
+			events[i] = r.Event
+		}
+		nameMap := make(map[string]string)
+		for _, follow := range config.Following {
+			if follow.Name != "" {
+				nameMap[follow.Key] = follow.Name
+			}
+		}
+		nameMap = fillNameMap(events, nameMap)
+		return searchDoneMsg{seq: seq, results: results, relays: relays, nameMap: nameMap}
+	}
+}
+
+func openSearch(m model) (model, tea.Cmd) {
+	m.screen = screenSearch
+	m.err = ""
+	m.searchInput.Reset()
+	m.searchInputActive = true
+	return m, m.searchInput.Focus()
+}
+
+func updateSearch(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchDoneMsg:
+		if msg.seq != m.searchSeq {
+			return m, nil
+		}
+		m.searchLoading = false
+		m.searchResults = msg.results
+		m.searchRelays = msg.relays
+		m.searchCur = 0
+		for k, v := range msg.nameMap {
+			if v != "" {
+				m.nameMap[k] = v
+			}
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.searchInputActive {
+			return updateSearchInput(m, msg)
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.searchCur > 0 {
+				m.searchCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.searchCur < len(m.searchResults)-1 {
+				m.searchCur++
+			}
+			return m, nil
+		case "enter", " ":
+			if m.searchCur >= len(m.searchResults) {
+				return m, nil
+			}
+			ev := m.searchResults[m.searchCur].Event
+			m.screen = screenDetail
+			m.detailReturn = screenSearch
+			m.detailStack = []nostr.Event{ev}
+			m.detailReplies = nil
+			m.detailReplyCur = 0
+			m.detailRepliesLoading = true
+			m.detailStatus = ""
+			return m, loadRepliesCmd(ev.ID)
+		case "p":
+			if m.searchCur < len(m.searchResults) {
+				return openProfile(m, m.searchResults[m.searchCur].Event.PubKey)
+			}
+			return m, nil
+		case "/", "s":
+			m.searchInputActive = true
+			return m, m.searchInput.Focus()
+		case "r":
+			if m.searchQuery == "" {
+				return m, nil
+			}
+			m.searchSeq++
+			m.searchLoading = true
+			return m, searchCmd(m.searchQuery, m.searchSeq)
+		case "u", "b", "esc":
+			m.searchSeq++
+			m.searchLoading = false
+			m.screen = screenMenu
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updateSearchInput reads the words to search for.
+func updateSearchInput(m model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.searchInputActive = false
+		m.searchInput.Blur()
+		if m.searchQuery == "" {
+			m.screen = screenMenu
+		}
+		return m, nil
+	case "enter":
+		text := strings.TrimSpace(m.searchInput.Value())
+		if len(tokenize(text)) == 0 {
+			return m, nil
+		}
+		m.searchInputActive = false
+		m.searchInput.Blur()
+		m.searchQuery = text
+		m.searchResults = nil
+		m.searchLoading = true
+		m.searchSeq++
+		return m, searchCmd(text, m.searchSeq)
+	}
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+func viewSearch(m model) string {
+	width := m.width - 30
+	if width < 20 {
+		width = 20
+	}
+	title := "1  Search"
+	if m.searchQuery != "" && !m.searchInputActive {
+		title += ": " + m.searchQuery
+	}
+	s := tuiStyle.Base.Render(title) + "\n\n"
+	if m.searchInputActive {
+		s += tuiStyle.Base.Render("Search: ") + m.searchInput.View() + "\n\n"
+		s += tuiStyle.Base.Render("i  [enter] search relays (NIP-50) and seen notes  [esc] cancel") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.searchLoading {
+		s += tuiStyle.Base.Render("i  Searching...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if len(m.searchRelays) == 0 {
+		s += tuiStyle.Base.Render("i  No relay supports NIP-50 search; only seen notes were searched.") + "\n"
+	} else if len(m.searchRelays) == 1 {
+		s += tuiStyle.Base.Render("i  Searched "+m.searchRelays[0]+" and the seen notes.") + "\n"
+	} else {
+		s += tuiStyle.Base.Render(fmt.Sprintf("i  Searched %d relays and the seen notes.", len(m.searchRelays))) + "\n"
+	}
+	if len(m.searchResults) == 0 {
+		s += tuiStyle.Base.Render("i  Nothing found.") + "\n"
+	}
+
+	avail := m.height - 9
+	if avail < 3 {
+		avail = 3
+	}
+	start := 0
+	if m.searchCur >= avail {
+		start = m.searchCur - avail + 1
+	}
+	for i := start; i < len(m.searchResults) && i < start+avail; i++ {
+		ev := m.searchResults[i].Event
+		author := m.nameMap[ev.PubKey]
+		if author == "" {
+			author = shorten(ev.PubKey)
+		}
+		line := "0  [" + author + "] " + draftPreview(ev.Content, width)
+		if i == m.searchCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [enter] open  [p] profile  [/] new search  [r] again  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
@@ -13,10 +13,12 @@ var tuiStyle = struct {
 	Screen lipgloss.Style
 	Base   lipgloss.Style
 	Cursor lipgloss.Style
+	Match  lipgloss.Style // text matching a filter
 }{
 	Screen: lipgloss.NewStyle().Background(lipgloss.Color("#000000")).Padding(0, 1),
 	Base:   lipgloss.NewStyle().Background(lipgloss.Color("#000000")).Foreground(lipgloss.Color(nostrPurple)),
 	Cursor: lipgloss.NewStyle().Background(lipgloss.Color(nostrPurpleDim)).Foreground(lipgloss.Color(nostrPurple)),
+	Match:  lipgloss.NewStyle().Background(lipgloss.Color(nostrPurple)).Foreground(lipgloss.Color("#000000")),
 }
 
 const gostrTitle = "    ________  ________  ________  _________  ________     \n   /  _____/ /  __  __\\/   ____/ /___   ___/|   __   \\    \n  /   \\  ___/  /  /  / \\____   \\    /  /    |  |__/  /    \n  \\    \\_\\  \\  \\__/  / /       /   /  /     |      _/     \n   \\________/\\______/ /_______/   /__/      |__|\\__\\      \n                                                          \n         --- NOSTR LIKE GOPHER | GOSTR ---"
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type candidatesLoadedMsg struct {
+	from       string
+	candidates []followCandidate
+	err        string
+}
+
+func loadCandidatesCmd(from string) tea.Cmd {
+	return func() tea.Msg {
+		if from == "" {
+			if len(config.Following) == 0 {
+				return candidatesLoadedMsg{err: "Follow someone first"}
+			}
+			return candidatesLoadedMsg{candidates: suggestFollows()}
+		}
+		candidates, err := followsOf(from)
+		if err != nil {
+			return candidatesLoadedMsg{from: from, err: err.Error()}
+		}
+		return candidatesLoadedMsg{from: from, candidates: candidates}
+	}
+}
+
+// openCandidates shows the follows of from to pick from, or follow
+// suggestions when from is empty.
+func openCandidates(m model, from string, back screen) (model, tea.Cmd) {
+	m.screen = screenCandidates
+	m.candidatesFrom = from
+	m.candidatesReturn = back
+	m.candidatesLoading = true
+	m.candidates = nil
+	m.candidateSel = make(map[string]bool)
+	m.candidateCur = 0
+	m.err = ""
+	return m, loadCandidatesCmd(from)
+}
+
+// followCandidates follows the selected candidates, or the one under the
+// cursor when none is selected, and drops them from the list.
+func followCandidates(m model) model {
+	var keep []followCandidate
+	followed := 0
+	for i, c := range m.candidates {
+		if m.candidateSel[c.Key] || (len(m.candidateSel) == 0 && i == m.candidateCur) {
+			config.Following[c.Key] = followCandidateEntry(c)
+			followed++
+			continue
+		}
+		keep = append(keep, c)
+	}
+	if followed == 0 {
+		return m
+	}
+	saveConfig(tuiConfigPath)
+	m.candidates = keep
+	m.candidateSel = make(map[string]bool)
+	if m.candidateCur >= len(m.candidates) {
+		m.candidateCur = len(m.candidates) - 1
+	}
+	if m.candidateCur < 0 {
+		m.candidateCur = 0
+	}
+	m.flash = fmt.Sprintf("Followed %d", followed)
+	return m
+}
+
+func updateCandidates(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case candidatesLoadedMsg:
+		if msg.from != m.candidatesFrom {
+			return m, nil
+		}
+		m.candidatesLoading = false
+		m.candidates = msg.candidates
+		m.err = msg.err
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.candidateCur > 0 {
+				m.candidateCur--
+			}
+			return m, nil
+		case "down", "j":
+			if m.candidateCur < len(m.candidates)-1 {
+				m.candidateCur++
+			}
+			return m, nil
+		case " ", "x":
+			if m.candidateCur < len(m.candidates) {
+				key := m.candidates[m.candidateCur].Key
+				if m.candidateSel[key] {
+					delete(m.candidateSel, key)
+				} else {
+					m.candidateSel[key] = true
+				}
+				if m.candidateCur < len(m.candidates)-1 {
+					m.candidateCur++
+				}
+			}
+			return m, nil
+		case "a":
+			if len(m.candidateSel) == len(m.candidates) {
+				m.candidateSel = make(map[string]bool)
+			} else {
+				for _, c := range m.candidates {
+					m.candidateSel[c.Key] = true
+				}
+			}
+			return m, nil
+		case "enter", "f":
+			if len(m.candidates) == 0 {
+				return m, nil
+			}
+			return followCandidates(m), nil
+		case "u", "b", "esc":
+			m.screen = m.candidatesReturn
+			m = refreshFollowing(m)
+			m.err = ""
+			if m.screen == screenFollow {
+				return m, m.followInput.Focus()
+			}
+			return m, nil
+		case "q":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func viewCandidates(m model) string {
+	title := "1  Suggestions: followed by people you follow"
+	if m.candidatesFrom != "" {
+		title = "1  Follows of " + shorten(m.candidatesFrom)
+	}
+	s := tuiStyle.Base.Render(title) + "\n\n"
+	if m.candidatesLoading {
+		s += tuiStyle.Base.Render("i  Loading contact lists...") + "\n"
+		return tuiStyle.Screen.Render(s)
+	}
+	if m.err != "" {
+		s += tuiStyle.Base.Render("i  "+m.err) + "\n"
+	}
+	if len(m.candidates) == 0 && m.err == "" {
+		s += tuiStyle.Base.Render("i  Nobody left to follow.") + "\n"
+	}
+
+	avail := m.height - 16
+	if avail < 5 {
+		avail = 5
+	}
+	start := 0
+	if m.candidateCur >= avail {
+		start = m.candidateCur - avail + 1
+	}
+	for i := start; i < len(m.candidates) && i < start+avail; i++ {
+		c := m.candidates[i]
+		mark := "[ ]"
+		if m.candidateSel[c.Key] {
+			mark = "[x]"
+		}
+		line := "1  " + mark + " " + c.displayName() + " (" + shorten(c.Key) + ")"
+		if m.candidatesFrom == "" {
+			line += fmt.Sprintf("  followed by %d", c.Count)
+		}
+		if i == m.candidateCur {
+			s += tuiStyle.Cursor.Render(line) + "\n"
+		} else {
+			s += tuiStyle.Base.Render(line) + "\n"
+		}
+	}
+
+	// profile of the candidate under the cursor
+	if m.candidateCur < len(m.candidates) {
+		c := m.candidates[m.candidateCur]
+		s += "\n"
+		if c.Meta.NIP05 != "" {
+			s += tuiStyle.Base.Render("i  nip05: "+c.Meta.NIP05) + "\n"
+		}
+		if c.Meta.Website != "" {
+			s += tuiStyle.Base.Render("i  web: "+c.Meta.Website) + "\n"
+		}
+		about := strings.TrimSpace(c.Meta.About)
+		if about == "" {
+			about = "(no profile description)"
+		}
+		s += tuiStyle.Base.Render("i  "+draftPreview(about, m.width-10)) + "\n"
+	}
+	s += "\n" + tuiStyle.Base.Render("i  [j/k] move  [space] select  [a] all  [enter] follow  [u] back") + "\n"
+	return tuiStyle.Screen.Render(s)
+}
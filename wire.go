@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// relayWire is a bare websocket connection to a single relay. The pool hides
+// relay replies such as the NIP-20 OK message, so deliveries that need them
+// talk to the relay through this instead.
+type relayWire struct {
+	URL  string
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// dialRelay opens a websocket connection to url.
+func dialRelay(ctx context.Context, url string) (*relayWire, error) {
+	url = nostr.NormalizeURL(url)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error opening websocket to '%s': %w", url, err)
+	}
+	return &relayWire{URL: url, conn: conn}, nil
+}
+
+// write sends one protocol message, e.g. write("EVENT", ev).
+func (w *relayWire) write(msg ...interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteJSON(msg)
+}
+
+// read blocks for the next protocol message and returns its label and the
+// remaining raw elements. Non-JSON frames are skipped.
+func (w *relayWire) read() (string, []json.RawMessage, error) {
+	for {
+		typ, message, err := w.conn.ReadMessage()
+		if err != nil {
+			return "", nil, err
+		}
+		if typ != websocket.TextMessage {
+			continue
+		}
+		var arr []json.RawMessage
+		if err := json.Unmarshal(message, &arr); err != nil || len(arr) == 0 {
+			continue
+		}
+		var label string
+		if err := json.Unmarshal(arr[0], &label); err != nil {
+			continue
+		}
+		return label, arr[1:], nil
+	}
+}
+
+func (w *relayWire) Close() error {
+	return w.conn.Close()
+}
+
+// sendEventTo publishes ev to a single relay and waits for its NIP-20 OK
+// reply, calling sent (if not nil) once the event was written. accepted and
+// message are the relay's verdict; err is set when the relay could not be
+// reached or did not answer within timeout.
+func sendEventTo(url string, ev nostr.Event, timeout time.Duration, sent func()) (accepted bool, message string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	w, err := dialRelay(ctx, url)
+	if err != nil {
+		return false, "", err
+	}
+	defer w.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		w.conn.SetReadDeadline(deadline)
+	}
+	if err := w.write("EVENT", ev); err != nil {
+		return false, "", err
+	}
+	if sent != nil {
+		sent()
+	}
+	for {
+		label, args, err := w.read()
+		if err != nil {
+			return false, "", fmt.Errorf("no OK from relay: %w", err)
+		}
+		if label != "OK" || len(args) < 2 {
+			continue
+		}
+		var id string
+		json.Unmarshal(args[0], &id)
+		if id != ev.ID {
+			continue
+		}
+		json.Unmarshal(args[1], &accepted)
+		if len(args) > 2 {
+			json.Unmarshal(args[2], &message)
+		}
+		return accepted, message, nil
+	}
+}